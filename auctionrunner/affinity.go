@@ -0,0 +1,77 @@
+package auctionrunner
+
+import (
+	"code.cloudfoundry.org/auction/auctiontypes"
+	"code.cloudfoundry.org/rep"
+)
+
+// affinityFilter rejects the cells that break any of an auction's hard
+// affinity rules.  It runs after all the other filters.
+type affinityFilter struct {
+	rules []auctiontypes.AffinityRule
+}
+
+func (affinityFilter) Name() string { return "affinity" }
+
+func (f affinityFilter) FilterLRP(cell *Cell, lrp *rep.LRP) error {
+	return f.filter(cell)
+}
+
+func (f affinityFilter) FilterTask(cell *Cell, task *rep.Task) error {
+	return f.filter(cell)
+}
+
+func (f affinityFilter) filter(cell *Cell) error {
+	for _, rule := range f.rules {
+		if !rule.Hard {
+			continue
+		}
+		matches := affinityMatches(cell, rule)
+		if (rule.Anti && matches > 0) || (!rule.Anti && matches == 0) {
+			return auctiontypes.AffinityRuleError{Rule: rule}
+		}
+	}
+	return nil
+}
+
+// withAffinity returns the filters to place work with the given affinity
+// rules.
+func (filters placementFilters) withAffinity(rules []auctiontypes.AffinityRule) placementFilters {
+	for _, rule := range rules {
+		if rule.Hard {
+			return append(filters[:len(filters):len(filters)], affinityFilter{rules: rules})
+		}
+	}
+	return filters
+}
+
+// applyAffinity adds what the weighted affinity rules make of the cell to
+// its score.
+func applyAffinity(score auctiontypes.ScoreBreakdown, cell *Cell, rules []auctiontypes.AffinityRule) auctiontypes.ScoreBreakdown {
+	for _, rule := range rules {
+		if rule.Hard {
+			continue
+		}
+		weight := -rule.Weight
+		if rule.Anti {
+			weight = rule.Weight
+		}
+		score.Affinity += weight * float64(affinityMatches(cell, rule))
+	}
+	score.Total += score.Affinity
+	return score
+}
+
+// affinityMatches counts the instances on the cell of the rule's LRPs.
+func affinityMatches(cell *Cell, rule auctiontypes.AffinityRule) int {
+	matches := 0
+	for i := range cell.state.LRPs {
+		for _, processGuid := range rule.ProcessGuids {
+			if cell.state.LRPs[i].ProcessGuid == processGuid {
+				matches++
+				break
+			}
+		}
+	}
+	return matches
+}
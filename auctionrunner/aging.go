@@ -0,0 +1,43 @@
+package auctionrunner
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/auction/auctiontypes"
+)
+
+// AgingPolicy raises the priority that work is ordered by as it is tried
+// again and again or waits longer, so that small work passed over in favour
+// of larger or higher priority work is placed eventually.
+type AgingPolicy struct {
+	// AttemptsPerBoost adds one to the priority for every so many
+	// attempts already made.  A value <= 0 ignores attempts.  Work drained
+	// from the batch starts with no attempts, so through the runner this
+	// only counts once WithInflightLimitRequeue sends work round again.
+	AttemptsPerBoost int
+
+	// WaitPerBoost adds one to the priority for every so long waited since
+	// the work was queued.  A value <= 0 ignores the wait.
+	WaitPerBoost time.Duration
+
+	// MaxBoost caps the boost.  A value <= 0 means no cap.
+	MaxBoost int
+}
+
+// Boost returns how much to add to the priority of the given work.
+func (p AgingPolicy) Boost(record auctiontypes.AuctionRecord, now time.Time) int {
+	boost := 0
+	if p.AttemptsPerBoost > 0 {
+		boost += record.Attempts / p.AttemptsPerBoost
+	}
+	if p.WaitPerBoost > 0 && !record.QueueTime.IsZero() {
+		if waited := now.Sub(record.QueueTime); waited > 0 {
+			boost += int(waited / p.WaitPerBoost)
+		}
+	}
+
+	if p.MaxBoost > 0 && boost > p.MaxBoost {
+		return p.MaxBoost
+	}
+	return boost
+}
@@ -0,0 +1,51 @@
+package auctionrunner_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/auction/auctionrunner"
+	"code.cloudfoundry.org/auction/auctiontypes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AgingPolicy", func() {
+	var (
+		now    time.Time
+		record auctiontypes.AuctionRecord
+	)
+
+	BeforeEach(func() {
+		now = time.Now()
+		record = auctiontypes.AuctionRecord{Attempts: 5, QueueTime: now.Add(-3 * time.Minute)}
+	})
+
+	It("does not boost anything by default", func() {
+		Expect(auctionrunner.AgingPolicy{}.Boost(record, now)).To(Equal(0))
+	})
+
+	It("boosts by attempts", func() {
+		policy := auctionrunner.AgingPolicy{AttemptsPerBoost: 2}
+		Expect(policy.Boost(record, now)).To(Equal(2))
+	})
+
+	It("boosts by time waited", func() {
+		policy := auctionrunner.AgingPolicy{WaitPerBoost: time.Minute}
+		Expect(policy.Boost(record, now)).To(Equal(3))
+	})
+
+	It("adds the two together, up to the maximum", func() {
+		policy := auctionrunner.AgingPolicy{AttemptsPerBoost: 1, WaitPerBoost: time.Minute}
+		Expect(policy.Boost(record, now)).To(Equal(8))
+
+		policy.MaxBoost = 4
+		Expect(policy.Boost(record, now)).To(Equal(4))
+	})
+
+	It("does not boost work with no queue time for waiting", func() {
+		record.QueueTime = time.Time{}
+		policy := auctionrunner.AgingPolicy{WaitPerBoost: time.Minute}
+		Expect(policy.Boost(record, now)).To(Equal(0))
+	})
+})
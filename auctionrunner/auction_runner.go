@@ -10,6 +10,7 @@ import (
 
 	"code.cloudfoundry.org/auction/auctiontypes"
 	"code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/rep"
 	"code.cloudfoundry.org/workpool"
 )
 
@@ -24,6 +25,210 @@ type auctionRunner struct {
 	binPackFirstFitWeight         float64
 	startingContainerWeight       float64
 	startingContainerCountMaximum int
+	schedulerOptions              []SchedulerOption
+	minBatchWait                  time.Duration
+	maxBatchWait                  time.Duration
+	maxAuctionsPerRound           int
+	batchOptions                  []BatchOption
+	shutdownMode                  ShutdownMode
+	shutdownTimeout               time.Duration
+	cellDiscoveryRetryPolicy      RetryPolicy
+	stateFetchRetryPolicy         RetryPolicy
+	pipelineRounds                bool
+	reservations                  *inflightReservations
+	cellStateSource               CellStateSource
+	priorityClassifier            PriorityClassifier
+	constraintProvider            ConstraintProvider
+	cellStateCache                *CellStateCache
+	inflightLimitMaxAttempts      int
+
+	// inflightRound is the round still committing its placements, when
+	// rounds are pipelined.  It is only touched by the Run goroutine.
+	inflightRound *inflightRound
+}
+
+// inflightRound is a round whose placements are being committed while the
+// next round fetches the cells' state.
+type inflightRound struct {
+	done    chan struct{}
+	results auctiontypes.AuctionResults
+}
+
+func (r *inflightRound) finished() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
+}
+
+type RunnerOption func(*auctionRunner)
+
+// ShutdownMode says what the runner does with pending work when it is
+// signalled.
+type ShutdownMode int
+
+const (
+	// ShutdownImmediately returns as soon as any round in progress is done,
+	// leaving pending work in the batch.
+	ShutdownImmediately ShutdownMode = iota
+	// ShutdownDrain keeps holding auctions until the batch is empty, then
+	// hands back anything left when the timeout ran out.
+	ShutdownDrain
+	// ShutdownHandBack hands all pending work back to the delegate.
+	ShutdownHandBack
+)
+
+// WithGracefulShutdown sets what happens to pending work on shutdown.  Once
+// shutdown starts the batch stops accepting work.  In ShutdownDrain mode no
+// new round is started after timeout has passed; timeout <= 0 means no limit.
+func WithGracefulShutdown(mode ShutdownMode, timeout time.Duration) RunnerOption {
+	return func(a *auctionRunner) {
+		a.shutdownMode = mode
+		a.shutdownTimeout = timeout
+	}
+}
+
+// WithBatchWindow holds an auction back for minWait after work first
+// arrives, waiting another minWait each time more work arrives, but never
+// for longer than maxWait in total.  Work arriving in that window is auctioned
+// in the same round.
+func WithBatchWindow(minWait, maxWait time.Duration) RunnerOption {
+	return func(a *auctionRunner) {
+		if maxWait < minWait {
+			maxWait = minWait
+		}
+		a.minBatchWait = minWait
+		a.maxBatchWait = maxWait
+	}
+}
+
+// WithMaxAuctionsPerRound limits how many auctions are held in a single
+// round.  The rest are carried over to the next round.
+func WithMaxAuctionsPerRound(maxAuctions int) RunnerOption {
+	return func(a *auctionRunner) {
+		a.maxAuctionsPerRound = maxAuctions
+	}
+}
+
+// WithBatchCapacity limits how many LRP start and task auctions may wait for
+// an auction.  Submissions that do not fit are rejected with an
+// auctiontypes.BatchFullError.  A capacity <= 0 means no limit.
+func WithBatchCapacity(lrpStarts, tasks int) RunnerOption {
+	return func(a *auctionRunner) {
+		a.batchOptions = append(a.batchOptions, WithLRPCapacity(lrpStarts), WithTaskCapacity(tasks))
+	}
+}
+
+// WithJournal keeps the runner's pending auctions in the given journal, so
+// they survive a restart.
+func WithJournal(journal *Journal) RunnerOption {
+	return func(a *auctionRunner) {
+		a.batchOptions = append(a.batchOptions, WithBatchJournal(journal))
+	}
+}
+
+// WithCellDiscoveryRetryPolicy sets how FetchCellReps is retried when it
+// fails.  By default it is retried every second until it succeeds.  Once the
+// policy gives up, the pending auctions fail with
+// auctiontypes.ErrorCellCommunication.
+func WithCellDiscoveryRetryPolicy(retryPolicy RetryPolicy) RunnerOption {
+	return func(a *auctionRunner) {
+		a.cellDiscoveryRetryPolicy = retryPolicy
+	}
+}
+
+// WithStateFetchRetryPolicy sets how fetching the cells' state is retried
+// while no cell answers.  By default it is tried four times in a row.
+func WithStateFetchRetryPolicy(retryPolicy RetryPolicy) RunnerOption {
+	return func(a *auctionRunner) {
+		a.stateFetchRetryPolicy = retryPolicy
+	}
+}
+
+// WithPipelinedRounds lets the next round fetch the cells' state while the
+// current round is still committing its placements.  Placements the fetched
+// state may not show yet are counted against their cells, so the next round
+// does not place work on the space they took.  Only one round commits at a
+// time.
+func WithPipelinedRounds() RunnerOption {
+	return func(a *auctionRunner) {
+		a.pipelineRounds = true
+	}
+}
+
+// WithInflightReservations remembers the work each round places until the
+// cell's state shows it, and counts it against the cell in later rounds in
+// the meantime.  Work a cell still does not show after ttl is forgotten.  A
+// ttl <= 0 turns this off.
+func WithInflightReservations(ttl time.Duration) RunnerOption {
+	return func(a *auctionRunner) {
+		if ttl <= 0 {
+			a.reservations = nil
+			return
+		}
+		a.reservations = newInflightReservations(ttl)
+	}
+}
+
+// WithCellStateSource takes the cells' state from source instead of asking
+// each cell for it.  The cells to consider, and the clients their work is
+// sent through, still come from the delegate's FetchCellReps.
+func WithCellStateSource(source CellStateSource) RunnerOption {
+	return func(a *auctionRunner) {
+		a.cellStateSource = source
+	}
+}
+
+// WithCellStateCache reuses each cell's state for up to maxAge instead of
+// asking every cell for it every round.  A cell is asked again once work is
+// sent to or stopped on it, or once asking it fails.  A maxAge <= 0 turns
+// this off.
+func WithCellStateCache(maxAge time.Duration) RunnerOption {
+	return func(a *auctionRunner) {
+		if maxAge <= 0 {
+			a.cellStateCache = nil
+			return
+		}
+		a.cellStateCache = NewCellStateCache(a.clock, maxAge)
+	}
+}
+
+// WithPriorityClassifier gives submitted work its priority, so that when
+// capacity is short higher priorities are placed first.
+func WithPriorityClassifier(classifier PriorityClassifier) RunnerOption {
+	return func(a *auctionRunner) {
+		a.priorityClassifier = classifier
+		a.batchOptions = append(a.batchOptions, WithBatchPriorityClassifier(classifier))
+	}
+}
+
+// WithConstraintProvider gives submitted work its placement constraints.
+func WithConstraintProvider(provider ConstraintProvider) RunnerOption {
+	return func(a *auctionRunner) {
+		a.constraintProvider = provider
+		a.batchOptions = append(a.batchOptions, WithBatchConstraintProvider(provider))
+	}
+}
+
+// WithInflightLimitRequeue puts work a round turned away only because the
+// in-flight start limit was reached back into the batch instead of failing
+// it, until it has been tried maxAttempts times.  Requeued work keeps its
+// attempts, so an AgingPolicy's AttemptsPerBoost raises it ahead of newer
+// work.  A maxAttempts <= 0 turns this off.
+func WithInflightLimitRequeue(maxAttempts int) RunnerOption {
+	return func(a *auctionRunner) {
+		a.inflightLimitMaxAttempts = maxAttempts
+	}
+}
+
+// WithSchedulerOptions passes the given options to the Scheduler built for
+// every auction.
+func WithSchedulerOptions(opts ...SchedulerOption) RunnerOption {
+	return func(a *auctionRunner) {
+		a.schedulerOptions = append(a.schedulerOptions, opts...)
+	}
 }
 
 func New(
@@ -35,18 +240,35 @@ func New(
 	binPackFirstFitWeight float64,
 	startingContainerWeight float64,
 	startingContainerCountMaximum int,
+	opts ...RunnerOption,
 ) *auctionRunner {
-	return &auctionRunner{
+	a := &auctionRunner{
 		logger:                        logger,
 		delegate:                      delegate,
 		metricEmitter:                 metricEmitter,
-		batch:                         NewBatch(clock),
 		clock:                         clock,
 		workPool:                      workPool,
 		binPackFirstFitWeight:         binPackFirstFitWeight,
 		startingContainerWeight:       startingContainerWeight,
 		startingContainerCountMaximum: startingContainerCountMaximum,
+		cellDiscoveryRetryPolicy:      defaultCellDiscoveryRetryPolicy,
+		stateFetchRetryPolicy:         defaultStateFetchRetryPolicy,
+		cellStateSource:               PullCellStateSource{},
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	// a capped round chooses what to carry over by the same aged priority
+	// the scheduler orders the work by
+	scheduler := NewScheduler(nil, nil, clock, logger, 0, 0, 0, a.schedulerOptions...)
+	if scheduler.agingPolicy != nil {
+		a.batchOptions = append(a.batchOptions, WithBatchAgingPolicy(*scheduler.agingPolicy))
 	}
+	a.batch = NewBatch(clock, a.batchOptions...)
+
+	return a
 }
 
 func (a *auctionRunner) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
@@ -54,86 +276,490 @@ func (a *auctionRunner) Run(signals <-chan os.Signal, ready chan<- struct{}) err
 
 	var hasWork chan Work
 	hasWork = a.batch.HasWork
+	fetchAttempts := 0
 
 	for {
 		select {
 		case work := <-hasWork:
-			logger := trace.LoggerWithTraceInfo(a.logger, work.TraceID).Session("auction")
+			if a.waitForMoreWork(signals) {
+				a.shutdown()
+				return nil
+			}
+
+			// a round carries work from many traces, so the round itself is not
+			// logged under any one of them
+			logger := a.logger.Session("auction")
 
 			logger.Info("fetching-cell-reps")
 			clients, err := a.delegate.FetchCellReps(logger, work.TraceID)
 			if err != nil {
 				logger.Error("failed-to-fetch-reps", err)
-				time.Sleep(time.Second)
-				hasWork = make(chan Work, 1)
-				hasWork <- work
-				break
+				fetchAttempts++
+				if a.cellDiscoveryRetryPolicy.CanRetry(fetchAttempts) {
+					if a.sleep(a.cellDiscoveryRetryPolicy.Delay(fetchAttempts), signals) {
+						a.shutdown()
+						return nil
+					}
+					hasWork = make(chan Work, 1)
+					hasWork <- work
+					break
+				}
+
+				// with no cells to offer the work to, the round fails every
+				// auction with a cell communication error
+				logger.Info("giving-up-fetching-cell-reps", lager.Data{"attempts": fetchAttempts})
+				clients = nil
+			} else {
+				logger.Info("fetched-cell-reps", lager.Data{"cell-reps-count": len(clients)})
 			}
-			logger.Info("fetched-cell-reps", lager.Data{"cell-reps-count": len(clients)})
 
+			fetchAttempts = 0
 			hasWork = a.batch.HasWork
 
-			logger.Info("fetching-zone-state")
-			fetchStatesStartTime := time.Now()
-			zones := FetchStateAndBuildZones(logger, a.workPool, clients, a.metricEmitter, a.binPackFirstFitWeight)
-			fetchStateDuration := time.Since(fetchStatesStartTime)
-			err = a.metricEmitter.FetchStatesCompleted(fetchStateDuration)
-			if err != nil {
-				logger.Error("failed-sending-fetch-states-completed-metric", err)
-			}
+			a.auction(logger, clients)
+		case <-signals:
+			a.shutdown()
+			return nil
+		}
+	}
+}
 
-			cellCount := 0
-			for zone, cells := range zones {
-				logger.Info("zone-state", lager.Data{"zone": zone, "cell-count": len(cells)})
-				cellCount += len(cells)
-			}
-			logger.Info("fetched-zone-state", lager.Data{
-				"cell-state-count":    cellCount,
-				"num-failed-requests": len(clients) - cellCount,
-				"duration":            fetchStateDuration.String(),
-			})
-
-			logger.Info("fetching-auctions")
-			lrpAuctions, taskAuctions := a.batch.DedupeAndDrain()
-			logger.Info("fetched-auctions", lager.Data{
-				"lrp-start-auctions": len(lrpAuctions),
-				"task-auctions":      len(taskAuctions),
-			})
-			if len(lrpAuctions) == 0 && len(taskAuctions) == 0 {
-				logger.Info("nothing-to-auction")
+// auction holds one round: it fetches the cells' state, drains the batch and
+// schedules what it drained.  Each auction is logged under the trace it was
+// submitted with.  When rounds are pipelined, auction returns once the work is
+// placed, leaving it to be committed in the background.
+func (a *auctionRunner) auction(logger lager.Logger, clients map[string]rep.Client) {
+	// only a round that is still committing when the state is fetched can
+	// be missing from that state
+	previousRound := a.inflightRound
+	if previousRound != nil && previousRound.finished() {
+		previousRound = nil
+	}
+
+	logger.Info("fetching-zone-state")
+	fetchStatesStartTime := time.Now()
+	zones := FetchStateAndBuildZonesWithRetry(logger, a.clock, a.stateFetchRetryPolicy, a.cellStateSource, a.cellStateCache, a.workPool, clients, a.metricEmitter, a.binPackFirstFitWeight)
+	fetchStateDuration := time.Since(fetchStatesStartTime)
+	err := a.metricEmitter.FetchStatesCompleted(fetchStateDuration)
+	if err != nil {
+		logger.Error("failed-sending-fetch-states-completed-metric", err)
+	}
+
+	cellCount := 0
+	for zone, cells := range zones {
+		logger.Info("zone-state", lager.Data{"zone": zone, "cell-count": len(cells)})
+		cellCount += len(cells)
+	}
+	logger.Info("fetched-zone-state", lager.Data{
+		"cell-state-count":    cellCount,
+		"num-failed-requests": len(clients) - cellCount,
+		"duration":            fetchStateDuration.String(),
+	})
+
+	a.waitForInflightRound()
+	if a.reservations != nil {
+		a.reservations.apply(logger, zones, a.clock.Now())
+	} else if previousRound != nil {
+		addPendingPlacements(logger, zones, previousRound.results)
+	}
+
+	logger.Info("fetching-auctions")
+	lrpAuctions, taskAuctions := a.batch.DedupeAndDrainUpTo(a.maxAuctionsPerRound)
+	a.emitBatchDepth(logger)
+	auctionRequest := auctiontypes.AuctionRequest{
+		LRPs:  lrpAuctions,
+		Tasks: taskAuctions,
+	}
+	traceIDs := auctionRequest.TraceIDs()
+	logger.Info("fetched-auctions", lager.Data{
+		"lrp-start-auctions": len(lrpAuctions),
+		"task-auctions":      len(taskAuctions),
+		"trace-ids":          traceIDs,
+	})
+	if len(lrpAuctions) == 0 && len(taskAuctions) == 0 {
+		logger.Info("nothing-to-auction")
+		return
+	}
+
+	logger.Info("scheduling")
+
+	scheduler := NewScheduler(a.workPool, zones, a.clock, logger, a.binPackFirstFitWeight, a.startingContainerWeight, a.startingContainerCountMaximum, a.schedulerOptions...)
+	round := &inflightRound{done: make(chan struct{})}
+	schedule := func() {
+		defer close(round.done)
+
+		auctionResults := scheduler.Schedule(auctionRequest)
+		auctionResults = a.requeueInflightLimited(logger, auctionResults)
+		logger.Info("scheduled", lager.Data{
+			"successful-lrp-start-auctions": len(auctionResults.SuccessfulLRPs),
+			"successful-task-auctions":      len(auctionResults.SuccessfulTasks),
+			"failed-lrp-start-auctions":     len(auctionResults.FailedLRPs),
+			"failed-task-auctions":          len(auctionResults.FailedTasks),
+		})
+
+		err := a.metricEmitter.AuctionCompleted(auctionResults)
+		if err != nil {
+			logger.Debug("failed-emitting-auction-complete-metrics", lager.Data{"error": err})
+		}
+		if a.reservations != nil {
+			a.reservations.add(auctionResults, a.clock.Now())
+		}
+		if a.cellStateCache != nil {
+			a.cellStateCache.InvalidatePlacements(auctionResults)
+		}
+		a.batch.FinishRound()
+		a.delegate.AuctionCompleted(logger, traceIDs, auctionResults)
+		round.results = auctionResults
+	}
+
+	if !a.pipelineRounds {
+		schedule()
+		return
+	}
+
+	a.inflightRound = round
+	go schedule()
+}
+
+// requeueInflightLimited puts the failures caused only by the in-flight
+// start limit back into the batch, when the runner is set to, and returns
+// the results without them.
+func (a *auctionRunner) requeueInflightLimited(logger lager.Logger, results auctiontypes.AuctionResults) auctiontypes.AuctionResults {
+	if a.inflightLimitMaxAttempts <= 0 {
+		return results
+	}
+
+	inflightLimited := auctiontypes.ErrorExceededInflightCreation.Error()
+	canRequeue := func(record auctiontypes.AuctionRecord) bool {
+		return record.PlacementError == inflightLimited && record.Attempts < a.inflightLimitMaxAttempts
+	}
+
+	var requeuedLRPs []auctiontypes.LRPAuction
+	failedLRPs := []auctiontypes.LRPAuction{}
+	for _, auction := range results.FailedLRPs {
+		if canRequeue(auction.AuctionRecord) {
+			requeuedLRPs = append(requeuedLRPs, auction)
+		} else {
+			failedLRPs = append(failedLRPs, auction)
+		}
+	}
+
+	var requeuedTasks []auctiontypes.TaskAuction
+	failedTasks := []auctiontypes.TaskAuction{}
+	for _, auction := range results.FailedTasks {
+		if canRequeue(auction.AuctionRecord) {
+			requeuedTasks = append(requeuedTasks, auction)
+		} else {
+			failedTasks = append(failedTasks, auction)
+		}
+	}
+
+	if len(requeuedLRPs) == 0 && len(requeuedTasks) == 0 {
+		return results
+	}
+
+	logger.Info("requeueing-inflight-limited-auctions", lager.Data{
+		"lrp-start-auctions": len(requeuedLRPs),
+		"task-auctions":      len(requeuedTasks),
+	})
+	a.batch.Requeue(requeuedLRPs, requeuedTasks)
+	results.FailedLRPs = failedLRPs
+	results.FailedTasks = failedTasks
+	return results
+}
+
+// waitForInflightRound waits for the round still committing, if any, to
+// finish.
+func (a *auctionRunner) waitForInflightRound() {
+	if a.inflightRound == nil {
+		return
+	}
+	<-a.inflightRound.done
+	a.inflightRound = nil
+}
+
+// addPendingPlacements counts the work a round placed against the cells it
+// was placed on, where the given zones do not show it yet.  Work whose
+// placement is unknown is counted too.
+func addPendingPlacements(logger lager.Logger, zones map[string]Zone, results auctiontypes.AuctionResults) {
+	cells := map[string]*Cell{}
+	for _, zone := range zones {
+		for _, cell := range zone {
+			cells[cell.Guid] = cell
+		}
+	}
+
+	lrpAuctions := append(append([]auctiontypes.LRPAuction{}, results.SuccessfulLRPs...), results.UnknownLRPs...)
+	for i := range lrpAuctions {
+		if cell, ok := cells[lrpAuctions[i].Winner]; ok {
+			cell.addPendingLRP(&lrpAuctions[i].LRP)
+		}
+	}
+
+	taskAuctions := append(append([]auctiontypes.TaskAuction{}, results.SuccessfulTasks...), results.UnknownTasks...)
+	for i := range taskAuctions {
+		if cell, ok := cells[taskAuctions[i].Winner]; ok {
+			cell.addPendingTask(&taskAuctions[i].Task)
+		}
+	}
+
+	logger.Info("added-pending-placements", lager.Data{
+		"lrp-start-auctions": len(lrpAuctions),
+		"task-auctions":      len(taskAuctions),
+	})
+}
+
+// shutdown runs once the runner has been signalled.  It first waits for any
+// round still committing.  Depending on the shutdown mode it returns straight
+// away, or stops accepting work and then auctions what is pending or hands
+// it back to the delegate.
+func (a *auctionRunner) shutdown() {
+	a.waitForInflightRound()
+
+	if a.shutdownMode == ShutdownImmediately {
+		return
+	}
+
+	logger := a.logger.Session("shutdown")
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	a.batch.Close()
+
+	if a.shutdownMode == ShutdownDrain {
+		var deadline time.Time
+		if a.shutdownTimeout > 0 {
+			deadline = a.clock.Now().Add(a.shutdownTimeout)
+		}
+
+		for a.batch.hasPending() {
+			if !deadline.IsZero() && !a.clock.Now().Before(deadline) {
+				logger.Info("deadline-exceeded")
 				break
 			}
 
-			logger.Info("scheduling")
-			auctionRequest := auctiontypes.AuctionRequest{
-				LRPs:  lrpAuctions,
-				Tasks: taskAuctions,
+			clients, err := a.delegate.FetchCellReps(logger, "")
+			if err != nil {
+				logger.Error("failed-to-fetch-reps", err)
+				break
 			}
+			a.auction(logger, clients)
+		}
+		a.waitForInflightRound()
+	}
 
-			scheduler := NewScheduler(a.workPool, zones, a.clock, logger, a.binPackFirstFitWeight, a.startingContainerWeight, a.startingContainerCountMaximum)
-			auctionResults := scheduler.Schedule(auctionRequest)
-			logger.Info("scheduled", lager.Data{
-				"successful-lrp-start-auctions": len(auctionResults.SuccessfulLRPs),
-				"successful-task-auctions":      len(auctionResults.SuccessfulTasks),
-				"failed-lrp-start-auctions":     len(auctionResults.FailedLRPs),
-				"failed-task-auctions":          len(auctionResults.FailedTasks),
-			})
+	lrpAuctions, taskAuctions := a.batch.DedupeAndDrain()
+	a.batch.FinishRound()
+	if len(lrpAuctions) == 0 && len(taskAuctions) == 0 {
+		return
+	}
 
-			err = a.metricEmitter.AuctionCompleted(auctionResults)
-			if err != nil {
-				logger.Debug("failed-emitting-auction-complete-metrics", lager.Data{"error": err})
+	logger.Info("handing-back-auctions", lager.Data{
+		"lrp-start-auctions": len(lrpAuctions),
+		"task-auctions":      len(taskAuctions),
+	})
+	a.delegate.HandBackAuctions(logger, auctiontypes.AuctionRequest{
+		LRPs:  lrpAuctions,
+		Tasks: taskAuctions,
+	})
+}
+
+// sleep waits on the runner's clock.  It returns true if the runner was
+// signalled while waiting.
+func (a *auctionRunner) sleep(d time.Duration, signals <-chan os.Signal) bool {
+	timer := a.clock.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return false
+	case <-signals:
+		return true
+	}
+}
+
+// waitForMoreWork keeps the batch open for the configured window so that
+// work arriving shortly after the first submission shares its auction.  It
+// returns true if the runner was signalled while waiting.
+func (a *auctionRunner) waitForMoreWork(signals <-chan os.Signal) bool {
+	if a.minBatchWait <= 0 {
+		return false
+	}
+
+	deadline := a.clock.Now().Add(a.maxBatchWait)
+	timer := a.clock.NewTimer(a.minBatchWait)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C():
+			return false
+		case <-a.batch.HasWork:
+			remaining := deadline.Sub(a.clock.Now())
+			if remaining <= 0 {
+				return false
 			}
-			a.delegate.AuctionCompleted(logger, work.TraceID, auctionResults)
+			if remaining > a.minBatchWait {
+				remaining = a.minBatchWait
+			}
+			timer.Reset(remaining)
 		case <-signals:
-			return nil
+			return true
 		}
 	}
 }
 
-func (a *auctionRunner) ScheduleLRPsForAuctions(lrpStarts []auctioneer.LRPStartRequest, traceID string) {
-	a.batch.AddLRPStarts(lrpStarts, traceID)
+func (a *auctionRunner) ScheduleLRPsForAuctions(lrpStarts []auctioneer.LRPStartRequest, traceID string) error {
+	err := a.batch.AddLRPStarts(lrpStarts, traceID)
+	a.recordAdmission(traceID, err)
+	return err
+}
+
+func (a *auctionRunner) ScheduleTasksForAuctions(tasks []auctioneer.TaskStartRequest, traceID string) error {
+	err := a.batch.AddTasks(tasks, traceID)
+	a.recordAdmission(traceID, err)
+	return err
+}
+
+// CancelLRPAuction removes a pending start for the given LRP instance from
+// the batch.  It returns auctiontypes.ErrorAuctionInProgress if the start is
+// already being auctioned.
+func (a *auctionRunner) CancelLRPAuction(processGuid string, index int) error {
+	logger := a.logger.Session("cancel-lrp-auction", lager.Data{"process-guid": processGuid, "index": index})
+
+	err := a.batch.CancelLRPStart(processGuid, index)
+	if err != nil {
+		logger.Info("failed-to-cancel", lager.Data{"reason": err.Error()})
+		return err
+	}
+
+	logger.Info("cancelled")
+	a.emitBatchDepth(logger)
+	return nil
 }
 
-func (a *auctionRunner) ScheduleTasksForAuctions(tasks []auctioneer.TaskStartRequest, traceID string) {
-	a.batch.AddTasks(tasks, traceID)
+// CancelTaskAuction removes a pending task from the batch.  It returns
+// auctiontypes.ErrorAuctionInProgress if the task is already being
+// auctioned.
+func (a *auctionRunner) CancelTaskAuction(taskGuid string) error {
+	logger := a.logger.Session("cancel-task-auction", lager.Data{"task-guid": taskGuid})
+
+	err := a.batch.CancelTask(taskGuid)
+	if err != nil {
+		logger.Info("failed-to-cancel", lager.Data{"reason": err.Error()})
+		return err
+	}
+
+	logger.Info("cancelled")
+	a.emitBatchDepth(logger)
+	return nil
+}
+
+func (a *auctionRunner) recordAdmission(traceID string, err error) {
+	logger := trace.LoggerWithTraceInfo(a.logger, traceID).Session("schedule")
+
+	if batchFull, ok := err.(auctiontypes.BatchFullError); ok {
+		logger.Info("batch-full", lager.Data{
+			"work-type": batchFull.WorkType,
+			"capacity":  batchFull.Capacity,
+			"pending":   batchFull.Pending,
+			"submitted": batchFull.Submitted,
+		})
+
+		lrpStarts, tasks := 0, 0
+		if batchFull.WorkType == auctiontypes.LRPStartWork {
+			lrpStarts = batchFull.Submitted
+		} else {
+			tasks = batchFull.Submitted
+		}
+		if emitErr := a.metricEmitter.BatchRejected(lrpStarts, tasks); emitErr != nil {
+			logger.Debug("failed-emitting-batch-rejected-metric", lager.Data{"error": emitErr})
+		}
+	}
+
+	a.emitBatchDepth(logger)
+}
+
+func (a *auctionRunner) emitBatchDepth(logger lager.Logger) {
+	lrpStarts, tasks := a.batch.Depth()
+	if err := a.metricEmitter.BatchDepth(lrpStarts, tasks); err != nil {
+		logger.Debug("failed-emitting-batch-depth-metric", lager.Data{"error": err})
+	}
+}
+
+// PlanAuctions runs a dry-run auction for the given work against the current
+// state of the cells.  The work is not added to the batch and nothing is sent
+// to the cells; see Scheduler.Plan.
+func (a *auctionRunner) PlanAuctions(lrpStarts []auctioneer.LRPStartRequest, tasks []auctioneer.TaskStartRequest, traceID string) (auctiontypes.AuctionResults, error) {
+	logger := trace.LoggerWithTraceInfo(a.logger, traceID).Session("plan")
+
+	logger.Info("fetching-cell-reps")
+	clients, err := a.delegate.FetchCellReps(logger, traceID)
+	if err != nil {
+		logger.Error("failed-to-fetch-reps", err)
+		return auctiontypes.AuctionResults{}, err
+	}
+	logger.Info("fetched-cell-reps", lager.Data{"cell-reps-count": len(clients)})
+
+	zones := FetchStateAndBuildZonesWithRetry(logger, a.clock, a.stateFetchRetryPolicy, a.cellStateSource, a.cellStateCache, a.workPool, clients, a.metricEmitter, a.binPackFirstFitWeight)
+
+	now := a.clock.Now()
+	if a.reservations != nil {
+		a.reservations.preview(logger, zones, now)
+	}
+
+	auctionRequest := auctiontypes.AuctionRequest{
+		LRPs:  buildLRPAuctions(lrpStarts, traceID, a.priorityClassifier, a.constraintProvider, now),
+		Tasks: buildTaskAuctions(tasks, traceID, a.priorityClassifier, a.constraintProvider, now),
+	}
+
+	scheduler := NewScheduler(a.workPool, zones, a.clock, logger, a.binPackFirstFitWeight, a.startingContainerWeight, a.startingContainerCountMaximum, a.schedulerOptions...)
+	auctionResults := scheduler.Plan(auctionRequest)
+	logger.Info("planned", lager.Data{
+		"successful-lrp-start-auctions": len(auctionResults.SuccessfulLRPs),
+		"successful-task-auctions":      len(auctionResults.SuccessfulTasks),
+		"failed-lrp-start-auctions":     len(auctionResults.FailedLRPs),
+		"failed-task-auctions":          len(auctionResults.FailedTasks),
+	})
+
+	return auctionResults, nil
+}
+
+// StopLRPInstances stops count running instances of the given process,
+// choosing them with Scheduler.ScheduleStop against the current state of the
+// cells.
+func (a *auctionRunner) StopLRPInstances(processGuid string, count int, traceID string) (auctiontypes.LRPStopResults, error) {
+	logger := trace.LoggerWithTraceInfo(a.logger, traceID).Session("stop-auction", lager.Data{"process-guid": processGuid, "count": count})
+
+	logger.Info("fetching-cell-reps")
+	clients, err := a.delegate.FetchCellReps(logger, traceID)
+	if err != nil {
+		logger.Error("failed-to-fetch-reps", err)
+		return auctiontypes.LRPStopResults{}, err
+	}
+	logger.Info("fetched-cell-reps", lager.Data{"cell-reps-count": len(clients)})
+
+	// in-flight reservations are left out: they have no instance a cell
+	// could be asked to stop
+	zones := FetchStateAndBuildZonesWithRetry(logger, a.clock, a.stateFetchRetryPolicy, a.cellStateSource, a.cellStateCache, a.workPool, clients, a.metricEmitter, a.binPackFirstFitWeight)
+
+	scheduler := NewScheduler(a.workPool, zones, a.clock, logger, a.binPackFirstFitWeight, a.startingContainerWeight, a.startingContainerCountMaximum, a.schedulerOptions...)
+	stopResults, err := scheduler.ScheduleStop(processGuid, count)
+	if a.cellStateCache != nil {
+		for _, stops := range [][]auctiontypes.LRPStop{stopResults.SuccessfulStops, stopResults.FailedStops} {
+			for i := range stops {
+				a.cellStateCache.Invalidate(stops[i].CellID)
+			}
+		}
+	}
+	if err != nil {
+		logger.Info("nothing-to-stop")
+		return stopResults, err
+	}
+	logger.Info("stopped", lager.Data{
+		"successful-stops": len(stopResults.SuccessfulStops),
+		"failed-stops":     len(stopResults.FailedStops),
+	})
+
+	return stopResults, nil
 }
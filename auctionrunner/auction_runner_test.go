@@ -0,0 +1,652 @@
+package auctionrunner_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/workpool"
+	"github.com/tedsuo/ifrit"
+
+	"code.cloudfoundry.org/auction/auctionrunner"
+	"code.cloudfoundry.org/auction/auctiontypes"
+	"code.cloudfoundry.org/auction/auctiontypes/fakes"
+	"code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/repfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AuctionRunner", func() {
+	var (
+		clock         *fakeclock.FakeClock
+		workPool      *workpool.WorkPool
+		delegate      *fakes.FakeAuctionRunnerDelegate
+		metricEmitter *fakes.FakeAuctionMetricEmitterDelegate
+		cellClient    *repfakes.FakeSimClient
+		options       []auctionrunner.RunnerOption
+		maxInflight   int
+		runner        auctiontypes.AuctionRunner
+		process       ifrit.Process
+	)
+
+	completedResults := func() auctiontypes.AuctionResults {
+		results := auctiontypes.AuctionResults{}
+		for i := 0; i < delegate.AuctionCompletedCallCount(); i++ {
+			_, _, r := delegate.AuctionCompletedArgsForCall(i)
+			results.SuccessfulLRPs = append(results.SuccessfulLRPs, r.SuccessfulLRPs...)
+			results.SuccessfulTasks = append(results.SuccessfulTasks, r.SuccessfulTasks...)
+			results.FailedLRPs = append(results.FailedLRPs, r.FailedLRPs...)
+			results.FailedTasks = append(results.FailedTasks, r.FailedTasks...)
+		}
+		return results
+	}
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+
+		var err error
+		workPool, err = workpool.NewWorkPool(5)
+		Expect(err).NotTo(HaveOccurred())
+
+		cellClient = &repfakes.FakeSimClient{}
+		cellClient.StateReturns(BuildCellState("cell", 0, "zone", 1000, 1000, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{}, []string{}, []string{}, []string{}, 0), nil)
+
+		delegate = &fakes.FakeAuctionRunnerDelegate{}
+		delegate.FetchCellRepsReturns(map[string]rep.Client{"cell": cellClient}, nil)
+		metricEmitter = &fakes.FakeAuctionMetricEmitterDelegate{}
+
+		options = nil
+		maxInflight = 0
+	})
+
+	JustBeforeEach(func() {
+		runner = auctionrunner.New(logger, delegate, metricEmitter, clock, workPool, 0.0, 0.0, maxInflight, options...)
+		process = ifrit.Invoke(runner)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive())
+		workPool.Stop()
+	})
+
+	It("auctions work as soon as it arrives", func() {
+		runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+			BuildLRPStartRequest("pg-1", "domain", []int{0}, linuxRootFSURL, 10, 10, 10, []string{}, []string{}),
+		}, "some-trace-id")
+
+		Eventually(delegate.AuctionCompletedCallCount).Should(Equal(1))
+		Expect(completedResults().SuccessfulLRPs).To(HaveLen(1))
+	})
+
+	Context("with a batch window", func() {
+		BeforeEach(func() {
+			options = append(options, auctionrunner.WithBatchWindow(time.Second, 5*time.Second))
+		})
+
+		It("waits for the window before auctioning, so later work joins the same round", func() {
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0}, linuxRootFSURL, 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			runner.ScheduleTasksForAuctions([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", linuxRootFSURL, 10, 10, 10),
+			}, "some-trace-id")
+
+			Consistently(delegate.FetchCellRepsCallCount).Should(Equal(0))
+
+			clock.WaitForWatcherAndIncrement(time.Second)
+
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(1))
+			results := completedResults()
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulTasks).To(HaveLen(1))
+		})
+
+		It("reports the trace of every auction in the round", func() {
+			err := runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0}, linuxRootFSURL, 10, 10, 10, []string{}, []string{}),
+			}, "trace-1")
+			Expect(err).NotTo(HaveOccurred())
+			err = runner.ScheduleTasksForAuctions([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", linuxRootFSURL, 10, 10, 10),
+			}, "trace-2")
+			Expect(err).NotTo(HaveOccurred())
+
+			clock.WaitForWatcherAndIncrement(time.Second)
+
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(1))
+			_, traceIDs, results := delegate.AuctionCompletedArgsForCall(0)
+			Expect(traceIDs).To(ConsistOf("trace-1", "trace-2"))
+			Expect(results.SuccessfulLRPs[0].TraceID).To(Equal("trace-1"))
+			Expect(results.SuccessfulTasks[0].TraceID).To(Equal("trace-2"))
+		})
+	})
+
+	Context("with a maximum number of auctions per round", func() {
+		BeforeEach(func() {
+			options = append(options, auctionrunner.WithMaxAuctionsPerRound(2))
+		})
+
+		It("carries the remaining auctions over to later rounds", func() {
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0, 1, 2, 3, 4}, linuxRootFSURL, 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(3))
+			for i := 0; i < 3; i++ {
+				_, _, results := delegate.AuctionCompletedArgsForCall(i)
+				Expect(len(results.SuccessfulLRPs)).To(BeNumerically("<=", 2))
+			}
+			Expect(completedResults().SuccessfulLRPs).To(HaveLen(5))
+		})
+
+		Context("with an aging policy", func() {
+			var release chan struct{}
+
+			BeforeEach(func() {
+				options = append(options,
+					auctionrunner.WithMaxAuctionsPerRound(1),
+					auctionrunner.WithPriorityClassifier(domainPriorities{"critical": 10}),
+					auctionrunner.WithSchedulerOptions(auctionrunner.WithAgingPolicy(auctionrunner.AgingPolicy{WaitPerBoost: time.Minute})),
+				)
+
+				release = make(chan struct{})
+				cellClient.PerformStub = func(lager.Logger, rep.Work) (rep.Work, error) {
+					<-release
+					return rep.Work{}, nil
+				}
+			})
+
+			It("carries over the work that has waited longest ahead of newer higher priority work", func() {
+				runner.ScheduleTasksForAuctions([]auctioneer.TaskStartRequest{
+					BuildTaskStartRequest("tg-1", "critical", linuxRootFSURL, 10, 10, 10),
+				}, "some-trace-id")
+				Eventually(cellClient.PerformCallCount).Should(Equal(1))
+
+				runner.ScheduleTasksForAuctions([]auctioneer.TaskStartRequest{
+					BuildTaskStartRequest("tg-2", "domain", linuxRootFSURL, 10, 10, 10),
+				}, "some-trace-id")
+				clock.Increment(20 * time.Minute)
+				runner.ScheduleTasksForAuctions([]auctioneer.TaskStartRequest{
+					BuildTaskStartRequest("tg-3", "critical", linuxRootFSURL, 10, 10, 10),
+				}, "some-trace-id")
+
+				close(release)
+
+				Eventually(delegate.AuctionCompletedCallCount).Should(Equal(3))
+				_, _, results := delegate.AuctionCompletedArgsForCall(1)
+				Expect(results.SuccessfulTasks).To(HaveLen(1))
+				Expect(results.SuccessfulTasks[0].TaskGuid).To(Equal("tg-2"))
+			})
+		})
+	})
+
+	Context("with work requeued at the in-flight start limit", func() {
+		var release chan struct{}
+
+		BeforeEach(func() {
+			maxInflight = 1
+			options = append(options, auctionrunner.WithInflightLimitRequeue(3))
+
+			release = make(chan struct{})
+			cellClient.PerformStub = func(lager.Logger, rep.Work) (rep.Work, error) {
+				<-release
+				return rep.Work{}, nil
+			}
+		})
+
+		// pg-2 is turned away in the first round; pg-3, larger, arrives
+		// while that round is committing and is auctioned alongside it
+		scheduleContendedWork := func() {
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0}, linuxRootFSURL, 30, 10, 10, []string{}, []string{}),
+				BuildLRPStartRequest("pg-2", "domain", []int{0}, linuxRootFSURL, 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			Eventually(cellClient.PerformCallCount).Should(Equal(1))
+
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-3", "domain", []int{0}, linuxRootFSURL, 20, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			close(release)
+		}
+
+		It("auctions the work again instead of failing it", func() {
+			scheduleContendedWork()
+
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(3))
+			_, _, results := delegate.AuctionCompletedArgsForCall(0)
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-1"))
+			Expect(results.FailedLRPs).To(BeEmpty())
+
+			_, _, results = delegate.AuctionCompletedArgsForCall(1)
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-3"))
+
+			_, _, results = delegate.AuctionCompletedArgsForCall(2)
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-2"))
+			Expect(results.SuccessfulLRPs[0].Attempts).To(Equal(3))
+		})
+
+		It("fails the work once it has been tried the maximum number of times", func() {
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0}, linuxRootFSURL, 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			Eventually(cellClient.PerformCallCount).Should(Equal(1))
+			close(release)
+
+			cellClient.StateReturns(BuildCellState("cell", 0, "zone", 1000, 1000, 100, false, 1, linuxOnlyRootFSProviders, []rep.LRP{}, []string{}, []string{}, []string{}, 0), nil)
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-2", "domain", []int{0}, linuxRootFSURL, 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+
+			Eventually(func() []auctiontypes.LRPAuction { return completedResults().FailedLRPs }).Should(HaveLen(1))
+			failed := completedResults().FailedLRPs[0]
+			Expect(failed.ProcessGuid).To(Equal("pg-2"))
+			Expect(failed.Attempts).To(Equal(3))
+			Expect(failed.PlacementError).To(Equal(auctiontypes.ErrorExceededInflightCreation.Error()))
+		})
+
+		Context("with an aging policy that counts attempts", func() {
+			BeforeEach(func() {
+				options = append(options,
+					auctionrunner.WithSchedulerOptions(auctionrunner.WithAgingPolicy(auctionrunner.AgingPolicy{AttemptsPerBoost: 1})),
+				)
+			})
+
+			It("places the requeued work ahead of newer work", func() {
+				scheduleContendedWork()
+
+				Eventually(delegate.AuctionCompletedCallCount).Should(Equal(3))
+				_, _, results := delegate.AuctionCompletedArgsForCall(1)
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-2"))
+
+				_, _, results = delegate.AuctionCompletedArgsForCall(2)
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-3"))
+			})
+		})
+	})
+
+	Context("with pipelined rounds", func() {
+		var release chan struct{}
+
+		BeforeEach(func() {
+			options = append(options, auctionrunner.WithPipelinedRounds())
+
+			cellClient.StateReturns(BuildCellState("cell", 0, "zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{}, []string{}, []string{}, []string{}, 0), nil)
+
+			release = make(chan struct{})
+			cellClient.PerformStub = func(lager.Logger, rep.Work) (rep.Work, error) {
+				<-release
+				return rep.Work{}, nil
+			}
+		})
+
+		It("fetches the next round's state while committing, without double-booking the cell", func() {
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0}, linuxRootFSURL, 60, 60, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			Eventually(cellClient.PerformCallCount).Should(Equal(1))
+
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-2", "domain", []int{0}, linuxRootFSURL, 60, 60, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			Eventually(cellClient.StateCallCount).Should(Equal(2))
+			Expect(delegate.AuctionCompletedCallCount()).To(Equal(0))
+
+			close(release)
+
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(2))
+			results := completedResults()
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-1"))
+			Expect(results.FailedLRPs).To(HaveLen(1))
+			Expect(results.FailedLRPs[0].ProcessGuid).To(Equal("pg-2"))
+		})
+	})
+
+	Context("with in-flight reservations", func() {
+		BeforeEach(func() {
+			options = append(options, auctionrunner.WithInflightReservations(time.Minute))
+
+			cellClient.StateReturns(BuildCellState("cell", 0, "zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{}, []string{}, []string{}, []string{}, 0), nil)
+		})
+
+		JustBeforeEach(func() {
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0}, linuxRootFSURL, 60, 60, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(1))
+		})
+
+		It("does not place work on capacity a cell has not yet shown as taken", func() {
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-2", "domain", []int{0}, linuxRootFSURL, 60, 60, 10, []string{}, []string{}),
+			}, "some-trace-id")
+
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(2))
+			_, _, results := delegate.AuctionCompletedArgsForCall(1)
+			Expect(results.FailedLRPs).To(HaveLen(1))
+			Expect(results.FailedLRPs[0].ProcessGuid).To(Equal("pg-2"))
+		})
+
+		It("does not count the work twice once the cell shows it", func() {
+			lrp := BuildLRP("pg-1", "domain", 0, linuxRootFSURL, 60, 60, 10, []string{})
+			cellClient.StateReturns(BuildCellState("cell", 0, "zone", 160, 160, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{*lrp}, []string{}, []string{}, []string{}, 0), nil)
+
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-2", "domain", []int{0}, linuxRootFSURL, 60, 60, 10, []string{}, []string{}),
+			}, "some-trace-id")
+
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(2))
+			_, _, results := delegate.AuctionCompletedArgsForCall(1)
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+		})
+
+		It("counts the work in plans", func() {
+			results, err := runner.PlanAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-2", "domain", []int{0}, linuxRootFSURL, 60, 60, 10, []string{}, []string{}),
+			}, nil, "some-trace-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results.FailedLRPs).To(HaveLen(1))
+		})
+
+		It("does not forget work while planning", func() {
+			lrp := BuildLRP("pg-1", "domain", 0, linuxRootFSURL, 60, 60, 10, []string{})
+			cellClient.StateReturns(BuildCellState("cell", 0, "zone", 160, 160, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{*lrp}, []string{}, []string{}, []string{}, 0), nil)
+			_, err := runner.PlanAuctions(nil, nil, "some-trace-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			cellClient.StateReturns(BuildCellState("cell", 0, "zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{}, []string{}, []string{}, []string{}, 0), nil)
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-2", "domain", []int{0}, linuxRootFSURL, 60, 60, 10, []string{}, []string{}),
+			}, "some-trace-id")
+
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(2))
+			_, _, results := delegate.AuctionCompletedArgsForCall(1)
+			Expect(results.FailedLRPs).To(HaveLen(1))
+		})
+
+		It("does not ask a cell to stop work it has not yet shown", func() {
+			_, err := runner.StopLRPInstances("pg-1", 1, "some-trace-id")
+			Expect(err).To(MatchError(auctiontypes.ErrorNothingToStop))
+			Expect(cellClient.StopLRPInstanceCallCount()).To(BeZero())
+
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-2", "domain", []int{0}, linuxRootFSURL, 60, 60, 10, []string{}, []string{}),
+			}, "some-trace-id")
+
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(2))
+			_, _, results := delegate.AuctionCompletedArgsForCall(1)
+			Expect(results.FailedLRPs).To(HaveLen(1))
+		})
+
+		It("forgets the work after the TTL", func() {
+			clock.Increment(time.Minute)
+
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-2", "domain", []int{0}, linuxRootFSURL, 60, 60, 10, []string{}, []string{}),
+			}, "some-trace-id")
+
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(2))
+			_, _, results := delegate.AuctionCompletedArgsForCall(1)
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-2"))
+		})
+	})
+
+	Context("with a cell state cache", func() {
+		BeforeEach(func() {
+			options = append(options, auctionrunner.WithCellStateCache(time.Minute))
+		})
+
+		It("reuses a cell's state until work is sent to it", func() {
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0}, linuxRootFSURL, 2000, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(1))
+
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-2", "domain", []int{0}, linuxRootFSURL, 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(2))
+			Expect(cellClient.StateCallCount()).To(Equal(1))
+
+			runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-3", "domain", []int{0}, linuxRootFSURL, 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(3))
+			Expect(cellClient.StateCallCount()).To(Equal(2))
+		})
+	})
+
+	Context("with a batch capacity", func() {
+		BeforeEach(func() {
+			options = append(options, auctionrunner.WithBatchCapacity(1, 1))
+			options = append(options, auctionrunner.WithBatchWindow(time.Second, time.Second))
+		})
+
+		It("returns a BatchFullError to the caller and records the rejection", func() {
+			err := runner.ScheduleTasksForAuctions([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", linuxRootFSURL, 10, 10, 10),
+			}, "some-trace-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = runner.ScheduleTasksForAuctions([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-2", "domain", linuxRootFSURL, 10, 10, 10),
+			}, "some-trace-id")
+			Expect(err).To(BeAssignableToTypeOf(auctiontypes.BatchFullError{}))
+
+			Expect(metricEmitter.BatchRejectedCallCount()).To(Equal(1))
+			lrpStarts, tasks := metricEmitter.BatchRejectedArgsForCall(0)
+			Expect(lrpStarts).To(BeZero())
+			Expect(tasks).To(Equal(1))
+
+			Expect(metricEmitter.BatchDepthCallCount()).To(Equal(2))
+			lrpStarts, tasks = metricEmitter.BatchDepthArgsForCall(1)
+			Expect(lrpStarts).To(BeZero())
+			Expect(tasks).To(Equal(1))
+		})
+
+		It("reports the depth left after each round", func() {
+			err := runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0}, linuxRootFSURL, 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			clock.WaitForWatcherAndIncrement(time.Second)
+
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(1))
+			Expect(metricEmitter.BatchDepthCallCount()).To(Equal(2))
+			lrpStarts, tasks := metricEmitter.BatchDepthArgsForCall(1)
+			Expect(lrpStarts).To(BeZero())
+			Expect(tasks).To(BeZero())
+		})
+	})
+
+	Describe("cancelling auctions", func() {
+		BeforeEach(func() {
+			options = append(options, auctionrunner.WithBatchWindow(time.Second, time.Second))
+		})
+
+		It("does not auction work cancelled while it was queued", func() {
+			err := runner.ScheduleTasksForAuctions([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", linuxRootFSURL, 10, 10, 10),
+				BuildTaskStartRequest("tg-2", "domain", linuxRootFSURL, 10, 10, 10),
+			}, "some-trace-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(runner.CancelTaskAuction("tg-1")).To(Succeed())
+
+			clock.WaitForWatcherAndIncrement(time.Second)
+
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(1))
+			results := completedResults()
+			Expect(results.SuccessfulTasks).To(HaveLen(1))
+			Expect(results.SuccessfulTasks[0].TaskGuid).To(Equal("tg-2"))
+		})
+
+		It("reports that it is too late once the auction is running", func() {
+			performed := make(chan struct{})
+			cellClient.PerformStub = func(lager.Logger, rep.Work) (rep.Work, error) {
+				<-performed
+				return rep.Work{}, nil
+			}
+
+			err := runner.ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0}, linuxRootFSURL, 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			clock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(cellClient.PerformCallCount).Should(Equal(1))
+
+			Expect(runner.CancelLRPAuction("pg-1", 0)).To(MatchError(auctiontypes.ErrorAuctionInProgress))
+
+			close(performed)
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(1))
+			Expect(runner.CancelLRPAuction("pg-1", 0)).To(MatchError(auctiontypes.ErrorAuctionNotFound))
+		})
+	})
+
+	Describe("shutting down", func() {
+		var handedBack func() auctiontypes.AuctionRequest
+
+		BeforeEach(func() {
+			options = append(options, auctionrunner.WithBatchWindow(time.Second, time.Second))
+
+			handedBack = func() auctiontypes.AuctionRequest {
+				Expect(delegate.HandBackAuctionsCallCount()).To(Equal(1))
+				_, request := delegate.HandBackAuctionsArgsForCall(0)
+				return request
+			}
+		})
+
+		JustBeforeEach(func() {
+			err := runner.ScheduleTasksForAuctions([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", linuxRootFSURL, 10, 10, 10),
+				BuildTaskStartRequest("tg-2", "domain", linuxRootFSURL, 10, 10, 10),
+			}, "some-trace-id")
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(clock.WatcherCount).Should(Equal(1))
+		})
+
+		Context("by default", func() {
+			It("returns straight away, leaving pending work alone", func() {
+				process.Signal(os.Interrupt)
+				Eventually(process.Wait()).Should(Receive(BeNil()))
+
+				Expect(delegate.AuctionCompletedCallCount()).To(BeZero())
+				Expect(delegate.HandBackAuctionsCallCount()).To(BeZero())
+			})
+		})
+
+		Context("when handing back pending work", func() {
+			BeforeEach(func() {
+				options = append(options, auctionrunner.WithGracefulShutdown(auctionrunner.ShutdownHandBack, 0))
+			})
+
+			It("gives the pending auctions to the delegate", func() {
+				process.Signal(os.Interrupt)
+				Eventually(process.Wait()).Should(Receive(BeNil()))
+
+				Expect(delegate.AuctionCompletedCallCount()).To(BeZero())
+				request := handedBack()
+				Expect(request.LRPs).To(BeEmpty())
+				Expect(request.Tasks).To(HaveLen(2))
+			})
+
+			It("stops accepting work", func() {
+				process.Signal(os.Interrupt)
+				Eventually(process.Wait()).Should(Receive())
+
+				err := runner.ScheduleTasksForAuctions([]auctioneer.TaskStartRequest{
+					BuildTaskStartRequest("tg-3", "domain", linuxRootFSURL, 10, 10, 10),
+				}, "some-trace-id")
+				Expect(err).To(MatchError(auctiontypes.ErrorBatchClosed))
+			})
+		})
+
+		Context("when draining pending work", func() {
+			BeforeEach(func() {
+				options = append(options, auctionrunner.WithGracefulShutdown(auctionrunner.ShutdownDrain, time.Minute))
+			})
+
+			It("auctions the pending work before returning", func() {
+				process.Signal(os.Interrupt)
+				Eventually(process.Wait()).Should(Receive(BeNil()))
+
+				Expect(delegate.AuctionCompletedCallCount()).To(Equal(1))
+				Expect(completedResults().SuccessfulTasks).To(HaveLen(2))
+				Expect(delegate.HandBackAuctionsCallCount()).To(BeZero())
+			})
+
+			Context("when the timeout runs out", func() {
+				BeforeEach(func() {
+					options = append(options, auctionrunner.WithMaxAuctionsPerRound(1))
+					delegate.FetchCellRepsStub = func(lager.Logger, string) (map[string]rep.Client, error) {
+						clock.Increment(time.Minute)
+						return map[string]rep.Client{"cell": cellClient}, nil
+					}
+				})
+
+				It("hands back what it did not get to", func() {
+					process.Signal(os.Interrupt)
+					Eventually(process.Wait()).Should(Receive(BeNil()))
+
+					Expect(delegate.AuctionCompletedCallCount()).To(Equal(1))
+					Expect(completedResults().SuccessfulTasks).To(HaveLen(1))
+					Expect(handedBack().Tasks).To(HaveLen(1))
+				})
+			})
+		})
+	})
+
+	Describe("fetching cell reps", func() {
+		BeforeEach(func() {
+			delegate.FetchCellRepsReturnsOnCall(0, nil, errors.New("boom"))
+		})
+
+		It("retries on the runner's clock", func() {
+			err := runner.ScheduleTasksForAuctions([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", linuxRootFSURL, 10, 10, 10),
+			}, "some-trace-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(delegate.FetchCellRepsCallCount).Should(Equal(1))
+			Consistently(delegate.FetchCellRepsCallCount).Should(Equal(1))
+
+			clock.WaitForWatcherAndIncrement(time.Second)
+
+			Eventually(delegate.AuctionCompletedCallCount).Should(Equal(1))
+			Expect(completedResults().SuccessfulTasks).To(HaveLen(1))
+		})
+
+		Context("when the retry policy gives up", func() {
+			BeforeEach(func() {
+				options = append(options, auctionrunner.WithCellDiscoveryRetryPolicy(auctionrunner.RetryPolicy{MaxAttempts: 1}))
+			})
+
+			It("fails the pending auctions", func() {
+				err := runner.ScheduleTasksForAuctions([]auctioneer.TaskStartRequest{
+					BuildTaskStartRequest("tg-1", "domain", linuxRootFSURL, 10, 10, 10),
+				}, "some-trace-id")
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(delegate.AuctionCompletedCallCount).Should(Equal(1))
+				results := completedResults()
+				Expect(results.FailedTasks).To(HaveLen(1))
+				Expect(results.FailedTasks[0].PlacementError).To(Equal(auctiontypes.ErrorCellCommunication.Error()))
+				Expect(delegate.FetchCellRepsCallCount()).To(Equal(1))
+			})
+		})
+	})
+})
@@ -1,7 +1,9 @@
 package auctionrunner
 
 import (
+	"sort"
 	"sync"
+	"time"
 
 	"code.cloudfoundry.org/auction/auctiontypes"
 	"code.cloudfoundry.org/auctioneer"
@@ -18,63 +20,410 @@ type Work struct {
 type Batch struct {
 	lrpAuctions  []auctiontypes.LRPAuction
 	taskAuctions []auctiontypes.TaskAuction
+	lrpCapacity  int
+	taskCapacity int
 	lock         *sync.Mutex
 	HasWork      chan Work
 	clock        clock.Clock
+	journal      *Journal
+	classifier   PriorityClassifier
+	constraints  ConstraintProvider
+	agingPolicy  *AgingPolicy
+	closed       bool
+
+	// auctions handed out by the last drain and not yet finished
+	runningLRPs  map[string]struct{}
+	runningTasks map[string]struct{}
 }
 
-func NewBatch(clock clock.Clock) *Batch {
-	return &Batch{
-		lrpAuctions: []auctiontypes.LRPAuction{},
-		lock:        &sync.Mutex{},
-		clock:       clock,
-		HasWork:     make(chan Work, 1),
+type BatchOption func(*Batch)
+
+// WithLRPCapacity limits how many LRP start auctions may wait in the batch.
+// capacity <= 0 means no limit.
+func WithLRPCapacity(capacity int) BatchOption {
+	return func(b *Batch) {
+		b.lrpCapacity = capacity
 	}
 }
 
-func (b *Batch) AddLRPStarts(starts []auctioneer.LRPStartRequest, traceID string) {
-	auctions := make([]auctiontypes.LRPAuction, 0, len(starts))
-	now := b.clock.Now()
-	for i := range starts {
-		start := &starts[i]
-		for _, index := range start.Indices {
-			lrpKey := models.NewActualLRPKey(start.ProcessGuid, int32(index), start.Domain)
-			auction := auctiontypes.NewLRPAuction(rep.NewLRP("", lrpKey, start.Resource, start.PlacementConstraint), now)
-			auctions = append(auctions, auction)
+// WithTaskCapacity limits how many task auctions may wait in the batch.
+// capacity <= 0 means no limit.
+func WithTaskCapacity(capacity int) BatchOption {
+	return func(b *Batch) {
+		b.taskCapacity = capacity
+	}
+}
+
+// WithBatchJournal records the batch's contents in the given journal, and
+// starts the batch off with whatever the journal recovered.
+func WithBatchJournal(journal *Journal) BatchOption {
+	return func(b *Batch) {
+		b.journal = journal
+	}
+}
+
+// WithBatchPriorityClassifier gives the work added to the batch its priority.
+func WithBatchPriorityClassifier(classifier PriorityClassifier) BatchOption {
+	return func(b *Batch) {
+		b.classifier = classifier
+	}
+}
+
+// WithBatchAgingPolicy ages the work a capped drain chooses between, so that
+// low priority work left behind round after round is drained eventually.
+func WithBatchAgingPolicy(policy AgingPolicy) BatchOption {
+	return func(b *Batch) {
+		b.agingPolicy = &policy
+	}
+}
+
+// WithBatchConstraintProvider gives the work added to the batch its
+// placement constraints.
+func WithBatchConstraintProvider(provider ConstraintProvider) BatchOption {
+	return func(b *Batch) {
+		b.constraints = provider
+	}
+}
+
+func NewBatch(clock clock.Clock, opts ...BatchOption) *Batch {
+	b := &Batch{
+		lrpAuctions:  []auctiontypes.LRPAuction{},
+		lock:         &sync.Mutex{},
+		clock:        clock,
+		HasWork:      make(chan Work, 1),
+		runningLRPs:  map[string]struct{}{},
+		runningTasks: map[string]struct{}{},
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	if b.journal != nil {
+		lrpAuctions, taskAuctions := b.journal.Pending()
+		b.lrpAuctions = append(b.lrpAuctions, lrpAuctions...)
+		b.taskAuctions = append(b.taskAuctions, taskAuctions...)
+		if len(b.lrpAuctions) > 0 || len(b.taskAuctions) > 0 {
+			b.claimToHaveWork("")
 		}
 	}
 
+	return b
+}
+
+// AddLRPStarts adds an auction for every requested instance.  If they do not
+// all fit it adds none of them and returns an auctiontypes.BatchFullError.
+// With a journal, the auctions are only added once they have been recorded.
+func (b *Batch) AddLRPStarts(starts []auctioneer.LRPStartRequest, traceID string) error {
+	auctions := buildLRPAuctions(starts, traceID, b.classifier, b.constraints, b.clock.Now())
+
 	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return auctiontypes.ErrorBatchClosed
+	}
+
+	if err := checkCapacity(auctiontypes.LRPStartWork, b.lrpCapacity, len(b.lrpAuctions), len(auctions)); err != nil {
+		return err
+	}
+
+	if b.journal != nil {
+		if err := b.journal.appendLRPs(auctions); err != nil {
+			return err
+		}
+	}
+
 	b.lrpAuctions = append(b.lrpAuctions, auctions...)
 	b.claimToHaveWork(traceID)
-	b.lock.Unlock()
+	return nil
 }
 
-func (b *Batch) AddTasks(tasks []auctioneer.TaskStartRequest, traceID string) {
-	auctions := make([]auctiontypes.TaskAuction, 0, len(tasks))
-	now := b.clock.Now()
-	for i := range tasks {
-		auctions = append(auctions, auctiontypes.NewTaskAuction(tasks[i].Task, now))
-	}
+// AddTasks adds an auction for every task.  If they do not all fit it adds
+// none of them and returns an auctiontypes.BatchFullError.
+func (b *Batch) AddTasks(tasks []auctioneer.TaskStartRequest, traceID string) error {
+	auctions := buildTaskAuctions(tasks, traceID, b.classifier, b.constraints, b.clock.Now())
 
 	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return auctiontypes.ErrorBatchClosed
+	}
+
+	if err := checkCapacity(auctiontypes.TaskWork, b.taskCapacity, len(b.taskAuctions), len(auctions)); err != nil {
+		return err
+	}
+
+	if b.journal != nil {
+		if err := b.journal.appendTasks(auctions); err != nil {
+			return err
+		}
+	}
+
 	b.taskAuctions = append(b.taskAuctions, auctions...)
 	b.claimToHaveWork(traceID)
-	b.lock.Unlock()
+	return nil
+}
+
+// Close stops the batch accepting work.  Later adds return
+// auctiontypes.ErrorBatchClosed; what is already pending can still be drained.
+func (b *Batch) Close() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.closed = true
+}
+
+func (b *Batch) hasPending() bool {
+	lrpStarts, tasks := b.Depth()
+	return lrpStarts > 0 || tasks > 0
+}
+
+// Depth returns how many LRP start and task auctions are waiting.
+func (b *Batch) Depth() (lrpStarts, tasks int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return len(b.lrpAuctions), len(b.taskAuctions)
+}
+
+// CancelLRPStart removes every pending auction for the given LRP instance.
+// It returns auctiontypes.ErrorAuctionInProgress if the instance has already
+// been drained into a round that has not finished, and
+// auctiontypes.ErrorAuctionNotFound if there was nothing to cancel.
+func (b *Batch) CancelLRPStart(processGuid string, index int) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.removeLRPStart(processGuid, index) {
+		return cancelResult(b.runningLRPs, lrpIdentifier(processGuid, index))
+	}
+
+	if b.journal != nil {
+		// the journal logs its own failures; at worst the start comes back
+		// after a restart
+		b.journal.appendLRPCancel(processGuid, index)
+	}
+	return nil
+}
+
+// CancelTask removes every pending auction for the given task.  It returns
+// the same errors as CancelLRPStart.
+func (b *Batch) CancelTask(taskGuid string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.removeTask(taskGuid) {
+		return cancelResult(b.runningTasks, taskGuid)
+	}
+
+	if b.journal != nil {
+		b.journal.appendTaskCancel(taskGuid)
+	}
+	return nil
+}
+
+func (b *Batch) removeLRPStart(processGuid string, index int) bool {
+	id := lrpIdentifier(processGuid, index)
+
+	remaining := b.lrpAuctions[:0]
+	for _, auction := range b.lrpAuctions {
+		if auction.Identifier() != id {
+			remaining = append(remaining, auction)
+		}
+	}
+	removed := len(remaining) < len(b.lrpAuctions)
+	b.lrpAuctions = remaining
+	return removed
+}
+
+func (b *Batch) removeTask(taskGuid string) bool {
+	remaining := b.taskAuctions[:0]
+	for _, auction := range b.taskAuctions {
+		if auction.Identifier() != taskGuid {
+			remaining = append(remaining, auction)
+		}
+	}
+	removed := len(remaining) < len(b.taskAuctions)
+	b.taskAuctions = remaining
+	return removed
+}
+
+func lrpIdentifier(processGuid string, index int) string {
+	lrp := rep.LRP{ActualLRPKey: models.ActualLRPKey{ProcessGuid: processGuid, Index: int32(index)}}
+	return lrp.Identifier()
+}
+
+func cancelResult(running map[string]struct{}, id string) error {
+	if _, ok := running[id]; ok {
+		return auctiontypes.ErrorAuctionInProgress
+	}
+	return auctiontypes.ErrorAuctionNotFound
+}
+
+// Requeue puts auctions a round could not place back into the batch, ahead
+// of work added since, keeping what their records say about earlier
+// attempts.  They were accepted once already, so the batch's capacity and
+// whether it is closed do not apply.
+func (b *Batch) Requeue(lrpAuctions []auctiontypes.LRPAuction, taskAuctions []auctiontypes.TaskAuction) {
+	if len(lrpAuctions) == 0 && len(taskAuctions) == 0 {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	requeuedLRPs := make([]auctiontypes.LRPAuction, 0, len(lrpAuctions)+len(b.lrpAuctions))
+	for _, auction := range lrpAuctions {
+		auction.PlacementError = ""
+		requeuedLRPs = append(requeuedLRPs, auction)
+	}
+	b.lrpAuctions = append(requeuedLRPs, b.lrpAuctions...)
+
+	requeuedTasks := make([]auctiontypes.TaskAuction, 0, len(taskAuctions)+len(b.taskAuctions))
+	for _, auction := range taskAuctions {
+		auction.PlacementError = ""
+		requeuedTasks = append(requeuedTasks, auction)
+	}
+	b.taskAuctions = append(requeuedTasks, b.taskAuctions...)
+
+	b.compactJournal()
+	b.claimToHaveWork("")
+}
+
+// FinishRound marks the auctions handed out by the last drain as done, so
+// they are no longer reported as in progress.
+func (b *Batch) FinishRound() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.runningLRPs = map[string]struct{}{}
+	b.runningTasks = map[string]struct{}{}
+}
+
+func checkCapacity(workType string, capacity, pending, submitted int) error {
+	if capacity <= 0 || pending+submitted <= capacity {
+		return nil
+	}
+
+	return auctiontypes.BatchFullError{
+		WorkType:  workType,
+		Capacity:  capacity,
+		Pending:   pending,
+		Submitted: submitted,
+	}
 }
 
 func (b *Batch) DedupeAndDrain() ([]auctiontypes.LRPAuction, []auctiontypes.TaskAuction) {
+	return b.DedupeAndDrainUpTo(0)
+}
+
+// DedupeAndDrainUpTo drains at most maxAuctions auctions, taking LRP starts
+// and tasks in the order they drain, and sharing slots between work that
+// drains alike in proportion to how many of each kind are waiting.  Anything
+// left over stays in the batch, ahead of work added later, and the batch
+// claims to still have work.  maxAuctions <= 0 means no limit.
+func (b *Batch) DedupeAndDrainUpTo(maxAuctions int) ([]auctiontypes.LRPAuction, []auctiontypes.TaskAuction) {
 	b.lock.Lock()
-	lrpAuctions := b.lrpAuctions
-	taskAuctions := b.taskAuctions
+	defer b.lock.Unlock()
+
+	lrpAuctions := dedupeLRPAuctions(b.lrpAuctions)
+	taskAuctions := dedupeTaskAuctions(b.taskAuctions)
 	b.lrpAuctions = []auctiontypes.LRPAuction{}
 	b.taskAuctions = []auctiontypes.TaskAuction{}
 	select {
 	case <-b.HasWork:
 	default:
 	}
-	b.lock.Unlock()
 
+	total := len(lrpAuctions) + len(taskAuctions)
+	if maxAuctions <= 0 || total <= maxAuctions {
+		b.markRunning(lrpAuctions, taskAuctions)
+		b.compactJournal()
+		return lrpAuctions, taskAuctions
+	}
+
+	// what is left behind waits for a later round, so leave the lowest
+	// priorities behind, and of those the work queued last
+	now := b.clock.Now()
+	sort.SliceStable(lrpAuctions, func(i, j int) bool {
+		return b.drainsBefore(lrpAuctions[i].AuctionRecord, lrpAuctions[j].AuctionRecord, now)
+	})
+	sort.SliceStable(taskAuctions, func(i, j int) bool {
+		return b.drainsBefore(taskAuctions[i].AuctionRecord, taskAuctions[j].AuctionRecord, now)
+	})
+
+	// take whichever drains first; between work that drains alike, take
+	// from the kind that has had the smaller share of its auctions taken
+	lrpCount, taskCount := 0, 0
+	for lrpCount+taskCount < maxAuctions {
+		takeLRP := taskCount == len(taskAuctions)
+		if lrpCount < len(lrpAuctions) && taskCount < len(taskAuctions) {
+			lrp, task := lrpAuctions[lrpCount].AuctionRecord, taskAuctions[taskCount].AuctionRecord
+			switch {
+			case b.drainsBefore(lrp, task, now):
+				takeLRP = true
+			case b.drainsBefore(task, lrp, now):
+				takeLRP = false
+			default:
+				takeLRP = lrpCount*len(taskAuctions) <= taskCount*len(lrpAuctions)
+			}
+		}
+
+		if takeLRP {
+			lrpCount++
+		} else {
+			taskCount++
+		}
+	}
+
+	b.lrpAuctions = append(b.lrpAuctions, lrpAuctions[lrpCount:]...)
+	b.taskAuctions = append(b.taskAuctions, taskAuctions[taskCount:]...)
+	b.claimToHaveWork("")
+
+	lrpAuctions, taskAuctions = lrpAuctions[:lrpCount], taskAuctions[:taskCount]
+	b.markRunning(lrpAuctions, taskAuctions)
+	b.compactJournal()
+	return lrpAuctions, taskAuctions
+}
+
+// drainsBefore reports whether a capped drain should take the work in a
+// before the work in other.
+func (b *Batch) drainsBefore(a, other auctiontypes.AuctionRecord, now time.Time) bool {
+	priority, otherPriority := a.Priority, other.Priority
+	if b.agingPolicy != nil {
+		priority += b.agingPolicy.Boost(a, now)
+		otherPriority += b.agingPolicy.Boost(other, now)
+	}
+	if priority != otherPriority {
+		return priority > otherPriority
+	}
+	return a.QueueTime.Before(other.QueueTime)
+}
+
+// compactJournal rewrites the journal to hold only what is still pending.
+// If that fails the journal keeps the drained auctions too, and they will be
+// auctioned again after a restart.
+func (b *Batch) compactJournal() {
+	if b.journal != nil {
+		b.journal.compact(b.lrpAuctions, b.taskAuctions)
+	}
+}
+
+func (b *Batch) markRunning(lrpAuctions []auctiontypes.LRPAuction, taskAuctions []auctiontypes.TaskAuction) {
+	b.runningLRPs = make(map[string]struct{}, len(lrpAuctions))
+	for i := range lrpAuctions {
+		b.runningLRPs[lrpAuctions[i].Identifier()] = struct{}{}
+	}
+
+	b.runningTasks = make(map[string]struct{}, len(taskAuctions))
+	for i := range taskAuctions {
+		b.runningTasks[taskAuctions[i].Identifier()] = struct{}{}
+	}
+}
+
+func dedupeLRPAuctions(lrpAuctions []auctiontypes.LRPAuction) []auctiontypes.LRPAuction {
 	dedupedLRPAuctions := []auctiontypes.LRPAuction{}
 	presentLRPAuctions := map[string]bool{}
 	for _, startAuction := range lrpAuctions {
@@ -85,7 +434,10 @@ func (b *Batch) DedupeAndDrain() ([]auctiontypes.LRPAuction, []auctiontypes.Task
 		presentLRPAuctions[id] = true
 		dedupedLRPAuctions = append(dedupedLRPAuctions, startAuction)
 	}
+	return dedupedLRPAuctions
+}
 
+func dedupeTaskAuctions(taskAuctions []auctiontypes.TaskAuction) []auctiontypes.TaskAuction {
 	dedupedTaskAuctions := []auctiontypes.TaskAuction{}
 	presentTaskAuctions := map[string]bool{}
 	for _, taskAuction := range taskAuctions {
@@ -96,8 +448,47 @@ func (b *Batch) DedupeAndDrain() ([]auctiontypes.LRPAuction, []auctiontypes.Task
 		presentTaskAuctions[id] = true
 		dedupedTaskAuctions = append(dedupedTaskAuctions, taskAuction)
 	}
+	return dedupedTaskAuctions
+}
 
-	return dedupedLRPAuctions, dedupedTaskAuctions
+func buildLRPAuctions(starts []auctioneer.LRPStartRequest, traceID string, classifier PriorityClassifier, constraints ConstraintProvider, now time.Time) []auctiontypes.LRPAuction {
+	auctions := make([]auctiontypes.LRPAuction, 0, len(starts))
+	for i := range starts {
+		start := &starts[i]
+		priority := 0
+		if classifier != nil {
+			priority = classifier.LRPPriority(start)
+		}
+		var lrpConstraints auctiontypes.LRPConstraints
+		if constraints != nil {
+			lrpConstraints = constraints.LRPConstraints(start)
+		}
+		for _, index := range start.Indices {
+			lrpKey := models.NewActualLRPKey(start.ProcessGuid, int32(index), start.Domain)
+			auction := auctiontypes.NewLRPAuction(rep.NewLRP("", lrpKey, start.Resource, start.PlacementConstraint), now)
+			auction.TraceID = traceID
+			auction.Priority = priority
+			auction.Constraints = lrpConstraints
+			auctions = append(auctions, auction)
+		}
+	}
+	return auctions
+}
+
+func buildTaskAuctions(tasks []auctioneer.TaskStartRequest, traceID string, classifier PriorityClassifier, constraints ConstraintProvider, now time.Time) []auctiontypes.TaskAuction {
+	auctions := make([]auctiontypes.TaskAuction, 0, len(tasks))
+	for i := range tasks {
+		auction := auctiontypes.NewTaskAuction(tasks[i].Task, now)
+		auction.TraceID = traceID
+		if classifier != nil {
+			auction.Priority = classifier.TaskPriority(&tasks[i])
+		}
+		if constraints != nil {
+			auction.Constraints = constraints.TaskConstraints(&tasks[i])
+		}
+		auctions = append(auctions, auction)
+	}
+	return auctions
 }
 
 func (b *Batch) claimToHaveWork(traceID string) {
@@ -1,6 +1,7 @@
 package auctionrunner_test
 
 import (
+	"fmt"
 	"time"
 
 	"code.cloudfoundry.org/auction/auctionrunner"
@@ -39,7 +40,7 @@ var _ = Describe("Batch", func() {
 
 			It("makes the start auction available when drained", func() {
 				lrpAuctions, _ := batch.DedupeAndDrain()
-				Expect(lrpAuctions).To(ConsistOf(BuildLRPAuctions(lrpStart, clock.Now())))
+				Expect(lrpAuctions).To(ConsistOf(TracedLRPAuctions("some-trace-id", BuildLRPAuctions(lrpStart, clock.Now())...)))
 			})
 
 			It("should have work", func() {
@@ -55,7 +56,7 @@ var _ = Describe("Batch", func() {
 
 			It("makes the stop auction available when drained", func() {
 				_, taskAuctions := batch.DedupeAndDrain()
-				Expect(taskAuctions).To(ConsistOf(BuildTaskAuction(&task.Task, clock.Now())))
+				Expect(taskAuctions).To(ConsistOf(TracedTaskAuctions("some-trace-id", BuildTaskAuction(&task.Task, clock.Now()))))
 			})
 
 			It("should have work", func() {
@@ -64,6 +65,21 @@ var _ = Describe("Batch", func() {
 		})
 	})
 
+	It("records the trace each auction was submitted under", func() {
+		batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+			BuildLRPStartRequest("pg-1", "domain", []int{0}, "linux", 10, 10, 10, []string{}, []string{}),
+		}, "trace-1")
+		batch.AddTasks([]auctioneer.TaskStartRequest{
+			BuildTaskStartRequest("tg-1", "domain", "linux", 10, 10, 10),
+		}, "trace-2")
+
+		Expect(batch.HasWork).To(Receive(Equal(auctionrunner.Work{TraceID: "trace-1"})))
+
+		lrpAuctions, taskAuctions := batch.DedupeAndDrain()
+		Expect(lrpAuctions[0].TraceID).To(Equal("trace-1"))
+		Expect(taskAuctions[0].TraceID).To(Equal("trace-2"))
+	})
+
 	Describe("DedupeAndDrain", func() {
 		BeforeEach(func() {
 			batch.AddLRPStarts([]auctioneer.LRPStartRequest{
@@ -80,12 +96,12 @@ var _ = Describe("Batch", func() {
 
 		It("should dedupe any duplicate start auctions and stop auctions", func() {
 			lrpAuctions, taskAuctions := batch.DedupeAndDrain()
-			Expect(lrpAuctions).To(Equal([]auctiontypes.LRPAuction{
+			Expect(lrpAuctions).To(Equal(TracedLRPAuctions("some-trace-id",
 				BuildLRPAuction("pg-1", "domain", 1, "linux", 10, 10, 10, clock.Now(), []string{"driver-1"}, []string{"tag-1"}),
 				BuildLRPAuction("pg-2", "domain", 2, "linux", 10, 10, 10, clock.Now(), []string{"driver-2"}, []string{"tag-2"}),
-			}))
+			)))
 
-			Expect(taskAuctions).To(Equal([]auctiontypes.TaskAuction{
+			Expect(taskAuctions).To(Equal(TracedTaskAuctions("some-trace-id",
 				BuildTaskAuction(
 					BuildTask("tg-1", "domain", "linux", 10, 10, 10, []string{}, []string{}),
 					clock.Now(),
@@ -94,7 +110,7 @@ var _ = Describe("Batch", func() {
 					BuildTask("tg-2", "domain", "linux", 10, 10, 10, []string{}, []string{}),
 					clock.Now(),
 				),
-			}))
+			)))
 		})
 
 		It("should clear out its cache, so a subsequent call shouldn't fetch anything", func() {
@@ -115,4 +131,396 @@ var _ = Describe("Batch", func() {
 			Expect(batch.HasWork).NotTo(Receive())
 		})
 	})
+
+	Describe("DedupeAndDrainUpTo", func() {
+		BeforeEach(func() {
+			batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0, 1, 2, 3, 4, 5}, "linux", 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+
+			batch.AddTasks([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", "linux", 10, 10, 10),
+				BuildTaskStartRequest("tg-1", "domain", "linux", 10, 10, 10),
+				BuildTaskStartRequest("tg-2", "domain", "linux", 10, 10, 10),
+			}, "some-trace-id")
+		})
+
+		It("drains everything when there is no limit", func() {
+			lrpAuctions, taskAuctions := batch.DedupeAndDrainUpTo(0)
+			Expect(lrpAuctions).To(HaveLen(6))
+			Expect(taskAuctions).To(HaveLen(2))
+			Expect(batch.HasWork).NotTo(Receive())
+		})
+
+		It("drains at most the limit, sharing work that drains alike in proportion between lrps and tasks", func() {
+			lrpAuctions, taskAuctions := batch.DedupeAndDrainUpTo(4)
+			Expect(lrpAuctions).To(HaveLen(3))
+			Expect(taskAuctions).To(HaveLen(1))
+			Expect(lrpAuctions[0].Index).To(BeEquivalentTo(0))
+			Expect(taskAuctions[0].TaskGuid).To(Equal("tg-1"))
+		})
+
+		It("keeps the rest for the next round and claims to still have work", func() {
+			batch.DedupeAndDrainUpTo(4)
+			Expect(batch.HasWork).To(Receive())
+
+			lrpAuctions, taskAuctions := batch.DedupeAndDrainUpTo(4)
+			Expect(lrpAuctions).To(HaveLen(3))
+			Expect(lrpAuctions[0].Index).To(BeEquivalentTo(3))
+			Expect(taskAuctions).To(HaveLen(1))
+			Expect(taskAuctions[0].TaskGuid).To(Equal("tg-2"))
+			Expect(batch.HasWork).NotTo(Receive())
+		})
+
+		It("puts carried over work ahead of work added later", func() {
+			batch.DedupeAndDrainUpTo(4)
+			batch.AddTasks([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-3", "domain", "linux", 10, 10, 10),
+			}, "some-trace-id")
+
+			_, taskAuctions := batch.DedupeAndDrain()
+			Expect(taskAuctions).To(HaveLen(2))
+			Expect(taskAuctions[0].TaskGuid).To(Equal("tg-2"))
+			Expect(taskAuctions[1].TaskGuid).To(Equal("tg-3"))
+		})
+
+		It("drains work of a kind far outnumbered by the other", func() {
+			batch = auctionrunner.NewBatch(clock)
+			batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0}, "linux", 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			tasks := []auctioneer.TaskStartRequest{}
+			for i := 0; i < 100; i++ {
+				tasks = append(tasks, BuildTaskStartRequest(fmt.Sprintf("tg-%d", i), "domain", "linux", 10, 10, 10))
+			}
+			batch.AddTasks(tasks, "some-trace-id")
+
+			lrpAuctions, taskAuctions := batch.DedupeAndDrainUpTo(10)
+			Expect(lrpAuctions).To(HaveLen(1))
+			Expect(taskAuctions).To(HaveLen(9))
+		})
+
+		It("drains the work queued first, whatever its kind", func() {
+			batch = auctionrunner.NewBatch(clock)
+			batch.AddTasks([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", "linux", 10, 10, 10),
+			}, "some-trace-id")
+			clock.Increment(time.Second)
+			batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0, 1, 2}, "linux", 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+
+			lrpAuctions, taskAuctions := batch.DedupeAndDrainUpTo(1)
+			Expect(lrpAuctions).To(BeEmpty())
+			Expect(taskAuctions).To(HaveLen(1))
+		})
+	})
+
+	Describe("priorities", func() {
+		BeforeEach(func() {
+			batch = auctionrunner.NewBatch(clock, auctionrunner.WithBatchPriorityClassifier(domainPriorities{"critical": 10}))
+
+			batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0, 1}, "linux", 10, 10, 10, []string{}, []string{}),
+				BuildLRPStartRequest("pg-2", "critical", []int{0}, "linux", 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			batch.AddTasks([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", "linux", 10, 10, 10),
+				BuildTaskStartRequest("tg-2", "critical", "linux", 10, 10, 10),
+			}, "some-trace-id")
+		})
+
+		It("gives work the priority its classifier says", func() {
+			lrpAuctions, taskAuctions := batch.DedupeAndDrain()
+			Expect(lrpAuctions[0].Priority).To(Equal(0))
+			Expect(lrpAuctions[2].Priority).To(Equal(10))
+			Expect(taskAuctions[0].Priority).To(Equal(0))
+			Expect(taskAuctions[1].Priority).To(Equal(10))
+		})
+
+		It("drains the highest priorities first when it cannot drain everything", func() {
+			lrpAuctions, taskAuctions := batch.DedupeAndDrainUpTo(2)
+			Expect(lrpAuctions).To(HaveLen(1))
+			Expect(lrpAuctions[0].ProcessGuid).To(Equal("pg-2"))
+			Expect(taskAuctions).To(HaveLen(1))
+			Expect(taskAuctions[0].TaskGuid).To(Equal("tg-2"))
+		})
+
+		It("drains higher priority work of one kind ahead of lower priority work of the other", func() {
+			batch = auctionrunner.NewBatch(clock, auctionrunner.WithBatchPriorityClassifier(domainPriorities{"critical": 10}))
+			batch.AddTasks([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", "linux", 10, 10, 10),
+				BuildTaskStartRequest("tg-2", "domain", "linux", 10, 10, 10),
+				BuildTaskStartRequest("tg-3", "domain", "linux", 10, 10, 10),
+			}, "some-trace-id")
+			batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "critical", []int{0}, "linux", 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+
+			lrpAuctions, taskAuctions := batch.DedupeAndDrainUpTo(1)
+			Expect(lrpAuctions).To(HaveLen(1))
+			Expect(lrpAuctions[0].ProcessGuid).To(Equal("pg-1"))
+			Expect(taskAuctions).To(BeEmpty())
+		})
+
+		It("drains the work queued first among equal priorities", func() {
+			clock.Increment(time.Minute)
+			batch.AddTasks([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-3", "critical", "linux", 10, 10, 10),
+			}, "some-trace-id")
+
+			_, taskAuctions := batch.DedupeAndDrainUpTo(2)
+			Expect(taskAuctions).To(HaveLen(1))
+			Expect(taskAuctions[0].TaskGuid).To(Equal("tg-2"))
+		})
+
+		Context("with an aging policy", func() {
+			BeforeEach(func() {
+				batch = auctionrunner.NewBatch(clock,
+					auctionrunner.WithBatchPriorityClassifier(domainPriorities{"critical": 10}),
+					auctionrunner.WithBatchAgingPolicy(auctionrunner.AgingPolicy{WaitPerBoost: time.Minute}),
+				)
+
+				batch.AddTasks([]auctioneer.TaskStartRequest{
+					BuildTaskStartRequest("tg-1", "domain", "linux", 10, 10, 10),
+				}, "some-trace-id")
+			})
+
+			It("drains low priority work once it has waited long enough", func() {
+				for i := 0; i < 9; i++ {
+					clock.Increment(time.Minute)
+					batch.AddTasks([]auctioneer.TaskStartRequest{
+						BuildTaskStartRequest(fmt.Sprintf("critical-%d", i), "critical", "linux", 10, 10, 10),
+					}, "some-trace-id")
+
+					_, taskAuctions := batch.DedupeAndDrainUpTo(1)
+					Expect(taskAuctions).To(HaveLen(1))
+					Expect(taskAuctions[0].TaskGuid).To(Equal(fmt.Sprintf("critical-%d", i)))
+				}
+
+				clock.Increment(time.Minute)
+				batch.AddTasks([]auctioneer.TaskStartRequest{
+					BuildTaskStartRequest("critical-9", "critical", "linux", 10, 10, 10),
+				}, "some-trace-id")
+
+				_, taskAuctions := batch.DedupeAndDrainUpTo(1)
+				Expect(taskAuctions).To(HaveLen(1))
+				Expect(taskAuctions[0].TaskGuid).To(Equal("tg-1"))
+			})
+		})
+	})
+
+	Describe("constraints", func() {
+		It("gives LRPs the constraints their provider says", func() {
+			spread := &auctiontypes.SpreadConstraint{MaxSkew: 1, Mode: auctiontypes.SpreadHard}
+			batch = auctionrunner.NewBatch(clock, auctionrunner.WithBatchConstraintProvider(guidConstraints{
+				lrps: map[string]auctiontypes.LRPConstraints{"pg-1": {Spread: spread}},
+			}))
+
+			batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0, 1}, "linux", 10, 10, 10, []string{}, []string{}),
+				BuildLRPStartRequest("pg-2", "domain", []int{0}, "linux", 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+
+			lrpAuctions, _ := batch.DedupeAndDrain()
+			Expect(lrpAuctions).To(HaveLen(3))
+			Expect(lrpAuctions[0].Constraints.Spread).To(Equal(spread))
+			Expect(lrpAuctions[1].Constraints.Spread).To(Equal(spread))
+			Expect(lrpAuctions[2].Constraints.Spread).To(BeNil())
+		})
+
+		It("gives tasks the constraints their provider says", func() {
+			affinity := []auctiontypes.AffinityRule{{ProcessGuids: []string{"pg-1"}, Hard: true}}
+			batch = auctionrunner.NewBatch(clock, auctionrunner.WithBatchConstraintProvider(guidConstraints{
+				tasks: map[string]auctiontypes.TaskConstraints{"tg-1": {Affinity: affinity}},
+			}))
+
+			batch.AddTasks([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", "linux", 10, 10, 10),
+				BuildTaskStartRequest("tg-2", "domain", "linux", 10, 10, 10),
+			}, "some-trace-id")
+
+			_, taskAuctions := batch.DedupeAndDrain()
+			Expect(taskAuctions).To(HaveLen(2))
+			Expect(taskAuctions[0].Constraints.Affinity).To(Equal(affinity))
+			Expect(taskAuctions[1].Constraints.Affinity).To(BeEmpty())
+		})
+	})
+
+	Describe("capacity", func() {
+		BeforeEach(func() {
+			batch = auctionrunner.NewBatch(clock, auctionrunner.WithLRPCapacity(3), auctionrunner.WithTaskCapacity(1))
+		})
+
+		It("accepts work up to the capacity", func() {
+			err := batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0, 1, 2}, "linux", 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = batch.AddTasks([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", "linux", 10, 10, 10),
+			}, "some-trace-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			lrpStarts, tasks := batch.Depth()
+			Expect(lrpStarts).To(Equal(3))
+			Expect(tasks).To(Equal(1))
+		})
+
+		It("rejects all of the submitted lrp starts when they do not fit", func() {
+			err := batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0, 1}, "linux", 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(batch.HasWork).To(Receive())
+
+			err = batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-2", "domain", []int{0, 1}, "linux", 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			Expect(err).To(Equal(auctiontypes.BatchFullError{
+				WorkType:  auctiontypes.LRPStartWork,
+				Capacity:  3,
+				Pending:   2,
+				Submitted: 2,
+			}))
+			Expect(batch.HasWork).NotTo(Receive())
+
+			lrpAuctions, _ := batch.DedupeAndDrain()
+			Expect(lrpAuctions).To(HaveLen(2))
+			Expect(lrpAuctions[0].ProcessGuid).To(Equal("pg-1"))
+		})
+
+		It("rejects tasks when they do not fit", func() {
+			err := batch.AddTasks([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", "linux", 10, 10, 10),
+				BuildTaskStartRequest("tg-2", "domain", "linux", 10, 10, 10),
+			}, "some-trace-id")
+			Expect(err).To(BeAssignableToTypeOf(auctiontypes.BatchFullError{}))
+			Expect(err.(auctiontypes.BatchFullError).WorkType).To(Equal(auctiontypes.TaskWork))
+
+			_, tasks := batch.Depth()
+			Expect(tasks).To(BeZero())
+		})
+
+		It("makes room again once the batch is drained", func() {
+			err := batch.AddTasks([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", "linux", 10, 10, 10),
+			}, "some-trace-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			batch.DedupeAndDrain()
+
+			err = batch.AddTasks([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-2", "domain", "linux", 10, 10, 10),
+			}, "some-trace-id")
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("requeueing auctions", func() {
+		var lrpAuctions []auctiontypes.LRPAuction
+		var taskAuctions []auctiontypes.TaskAuction
+
+		BeforeEach(func() {
+			batch = auctionrunner.NewBatch(clock, auctionrunner.WithLRPCapacity(1))
+			batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0}, "linux", 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			batch.AddTasks([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", "linux", 10, 10, 10),
+			}, "some-trace-id")
+
+			lrpAuctions, taskAuctions = batch.DedupeAndDrain()
+			lrpAuctions[0].Attempts = 2
+			lrpAuctions[0].PlacementError = auctiontypes.ErrorExceededInflightCreation.Error()
+			taskAuctions[0].Attempts = 1
+		})
+
+		It("puts the auctions back ahead of work added since, keeping their attempts", func() {
+			batch.AddTasks([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-2", "domain", "linux", 10, 10, 10),
+			}, "some-trace-id")
+			Expect(batch.HasWork).To(Receive())
+
+			batch.Requeue(lrpAuctions, taskAuctions)
+			Expect(batch.HasWork).To(Receive())
+
+			requeuedLRPs, requeuedTasks := batch.DedupeAndDrain()
+			Expect(requeuedLRPs).To(HaveLen(1))
+			Expect(requeuedLRPs[0].Attempts).To(Equal(2))
+			Expect(requeuedLRPs[0].PlacementError).To(BeEmpty())
+			Expect(requeuedTasks).To(HaveLen(2))
+			Expect(requeuedTasks[0].TaskGuid).To(Equal("tg-1"))
+			Expect(requeuedTasks[0].Attempts).To(Equal(1))
+			Expect(requeuedTasks[1].TaskGuid).To(Equal("tg-2"))
+		})
+
+		It("takes them back even when the batch is full or closed", func() {
+			batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-2", "domain", []int{0}, "linux", 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			batch.Close()
+
+			batch.Requeue(lrpAuctions, taskAuctions)
+
+			lrpStarts, tasks := batch.Depth()
+			Expect(lrpStarts).To(Equal(2))
+			Expect(tasks).To(Equal(1))
+		})
+	})
+
+	Describe("cancelling auctions", func() {
+		BeforeEach(func() {
+			batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+				BuildLRPStartRequest("pg-1", "domain", []int{0, 1}, "linux", 10, 10, 10, []string{}, []string{}),
+				BuildLRPStartRequest("pg-1", "domain", []int{1}, "linux", 10, 10, 10, []string{}, []string{}),
+			}, "some-trace-id")
+			batch.AddTasks([]auctioneer.TaskStartRequest{
+				BuildTaskStartRequest("tg-1", "domain", "linux", 10, 10, 10),
+				BuildTaskStartRequest("tg-2", "domain", "linux", 10, 10, 10),
+			}, "some-trace-id")
+		})
+
+		It("removes every pending start for the lrp instance", func() {
+			Expect(batch.CancelLRPStart("pg-1", 1)).To(Succeed())
+
+			lrpAuctions, _ := batch.DedupeAndDrain()
+			Expect(lrpAuctions).To(HaveLen(1))
+			Expect(lrpAuctions[0].Index).To(BeEquivalentTo(0))
+		})
+
+		It("removes the pending task", func() {
+			Expect(batch.CancelTask("tg-1")).To(Succeed())
+
+			_, taskAuctions := batch.DedupeAndDrain()
+			Expect(taskAuctions).To(HaveLen(1))
+			Expect(taskAuctions[0].TaskGuid).To(Equal("tg-2"))
+		})
+
+		It("reports when there is nothing to cancel", func() {
+			Expect(batch.CancelLRPStart("pg-2", 0)).To(MatchError(auctiontypes.ErrorAuctionNotFound))
+			Expect(batch.CancelTask("tg-3")).To(MatchError(auctiontypes.ErrorAuctionNotFound))
+		})
+
+		Context("when the auctions have been drained into a round", func() {
+			BeforeEach(func() {
+				batch.DedupeAndDrainUpTo(2)
+			})
+
+			It("reports that it is too late to cancel them", func() {
+				Expect(batch.CancelLRPStart("pg-1", 0)).To(MatchError(auctiontypes.ErrorAuctionInProgress))
+				Expect(batch.CancelTask("tg-1")).To(MatchError(auctiontypes.ErrorAuctionInProgress))
+			})
+
+			It("still cancels the auctions carried over to the next round", func() {
+				Expect(batch.CancelTask("tg-2")).To(Succeed())
+			})
+
+			It("stops reporting them as in progress once the round finishes", func() {
+				batch.FinishRound()
+				Expect(batch.CancelTask("tg-1")).To(MatchError(auctiontypes.ErrorAuctionNotFound))
+			})
+		})
+	})
 })
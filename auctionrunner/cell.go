@@ -1,6 +1,9 @@
 package auctionrunner
 
 import (
+	"code.cloudfoundry.org/auction/auctiontypes"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/rep"
 )
@@ -49,6 +52,14 @@ func (c *Cell) State() rep.CellState {
 }
 
 func (c *Cell) ScoreForLRP(lrp *rep.LRP, startingContainerWeight, binPackFirstFitWeight float64) (float64, error) {
+	score, err := c.LRPScoreBreakdown(lrp, startingContainerWeight, binPackFirstFitWeight)
+	if err != nil {
+		return 0, err
+	}
+	return score.Total, nil
+}
+
+func (c *Cell) LRPScoreBreakdown(lrp *rep.LRP, startingContainerWeight, binPackFirstFitWeight float64) (auctiontypes.ScoreBreakdown, error) {
 	proxiedLRP := rep.Resource{
 		MemoryMB: lrp.Resource.MemoryMB + int32(c.state.ProxyMemoryAllocationMB),
 		DiskMB:   lrp.Resource.DiskMB,
@@ -57,7 +68,7 @@ func (c *Cell) ScoreForLRP(lrp *rep.LRP, startingContainerWeight, binPackFirstFi
 
 	err := c.state.ResourceMatch(&proxiedLRP)
 	if err != nil {
-		return 0, err
+		return auctiontypes.ScoreBreakdown{}, err
 	}
 
 	numberOfInstancesWithMatchingProcessGuid := 0
@@ -81,18 +92,35 @@ func (c *Cell) ScoreForLRP(lrp *rep.LRP, startingContainerWeight, binPackFirstFi
 		"index-score":    indexScore,
 		"score":          resourceScore + float64(localityScore) + indexScore,
 	})
-	return resourceScore + float64(localityScore) + indexScore, nil
+	return auctiontypes.ScoreBreakdown{
+		Resource: resourceScore,
+		Locality: float64(localityScore),
+		Index:    indexScore,
+		Total:    resourceScore + float64(localityScore) + indexScore,
+	}, nil
 }
 
 func (c *Cell) ScoreForTask(task *rep.Task, startingContainerWeight float64) (float64, error) {
-	err := c.state.ResourceMatch(&task.Resource)
+	score, err := c.TaskScoreBreakdown(task, startingContainerWeight)
 	if err != nil {
 		return 0, err
 	}
+	return score.Total, nil
+}
+
+func (c *Cell) TaskScoreBreakdown(task *rep.Task, startingContainerWeight float64) (auctiontypes.ScoreBreakdown, error) {
+	err := c.state.ResourceMatch(&task.Resource)
+	if err != nil {
+		return auctiontypes.ScoreBreakdown{}, err
+	}
 
 	localityScore := LocalityOffset * len(c.state.Tasks)
 	resourceScore := c.state.ComputeScore(&task.Resource, startingContainerWeight)
-	return resourceScore + float64(localityScore), nil
+	return auctiontypes.ScoreBreakdown{
+		Resource: resourceScore,
+		Locality: float64(localityScore),
+		Total:    resourceScore + float64(localityScore),
+	}, nil
 }
 
 func (c *Cell) ReserveLRP(lrp *rep.LRP) error {
@@ -117,18 +145,163 @@ func (c *Cell) ReserveTask(task *rep.Task) error {
 	return nil
 }
 
+// ReleaseLRP gives back the resources of an instance that is going to be
+// stopped, so later decisions see the cell as less loaded.
+func (c *Cell) ReleaseLRP(lrp *rep.LRP) {
+	c.removeLRP(lrp)
+}
+
+// cancelLRPReservation undoes ReserveLRP for an instance the cell refused.
+func (c *Cell) cancelLRPReservation(lrp *rep.LRP) {
+	if c.removeLRP(lrp) {
+		c.state.StartingContainerCount--
+	}
+}
+
+// cancelTaskReservation undoes ReserveTask for a task the cell refused.
+func (c *Cell) cancelTaskReservation(task *rep.Task) {
+	for i := range c.state.Tasks {
+		if c.state.Tasks[i].Identifier() == task.Identifier() {
+			c.state.Tasks = append(c.state.Tasks[:i:i], c.state.Tasks[i+1:]...)
+			c.state.AvailableResources.MemoryMB += task.MemoryMB
+			c.state.AvailableResources.DiskMB += task.DiskMB
+			c.state.AvailableResources.Containers++
+			c.state.StartingContainerCount--
+			return
+		}
+	}
+}
+
+// addPendingLRP accounts for an instance an earlier round sent the cell,
+// unless the cell's state already shows it.  Unlike ReserveLRP, the instance
+// is not sent to the cell again.  It returns false if the state showed it.
+func (c *Cell) addPendingLRP(lrp *rep.LRP) bool {
+	for i := range c.state.LRPs {
+		if c.state.LRPs[i].Identifier() == lrp.Identifier() {
+			return false
+		}
+	}
+	c.state.AddLRP(lrp)
+	return true
+}
+
+// addPendingTask is addPendingLRP for tasks.
+func (c *Cell) addPendingTask(task *rep.Task) bool {
+	for i := range c.state.Tasks {
+		if c.state.Tasks[i].Identifier() == task.Identifier() {
+			return false
+		}
+	}
+	c.state.AddTask(task)
+	return true
+}
+
+func (c *Cell) removeLRP(lrp *rep.LRP) bool {
+	for i := range c.state.LRPs {
+		if c.state.LRPs[i].InstanceGUID == lrp.InstanceGUID && c.state.LRPs[i].Identifier() == lrp.Identifier() {
+			c.state.LRPs = append(c.state.LRPs[:i:i], c.state.LRPs[i+1:]...)
+			c.state.AvailableResources.MemoryMB += lrp.MemoryMB
+			c.state.AvailableResources.DiskMB += lrp.DiskMB
+			c.state.AvailableResources.Containers++
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Cell) StopLRP(lrp *rep.LRP) error {
+	err := c.client.StopLRPInstance(c.logger, lrp.ActualLRPKey, models.NewActualLRPInstanceKey(lrp.InstanceGUID, c.Guid))
+	if err != nil {
+		c.logger.Error("failed-to-stop-lrp", err, lager.Data{"cell-guid": c.Guid, "lrp-guid": lrp.Identifier(), "lrp-instance-guid": lrp.InstanceGUID})
+	}
+	return err
+}
+
 func (c *Cell) Commit() rep.Work {
+	return c.commit(clock.NewClock(), defaultCommitRetryPolicy, false).failed
+}
+
+// commitResult says what became of the work sent to a cell.  Anything in
+// neither list was placed.
+type commitResult struct {
+	// failed is the work the cell refused, or was found not to have
+	// after an ambiguous error.
+	failed rep.Work
+	// unknown is the work that may or may not have been placed.
+	unknown rep.Work
+}
+
+// commit sends the cell the work reserved since the last commit, retrying as
+// the policy allows if the request fails outright.  If it still fails, the
+// cell may have taken on some of the work; with reconcile set the cell's
+// state is read again to find out which, otherwise all of it is taken to be
+// placed.
+func (c *Cell) commit(clk clock.Clock, retryPolicy RetryPolicy, reconcile bool) commitResult {
 	if len(c.workToCommit.LRPs) == 0 && len(c.workToCommit.Tasks) == 0 {
-		return rep.Work{}
+		return commitResult{}
 	}
 
-	failedWork, err := c.client.Perform(c.logger, c.workToCommit)
+	work := c.workToCommit
+	c.workToCommit = rep.Work{CellID: c.Guid}
+
+	var failedWork rep.Work
+	err := retryPolicy.Do(clk, func() error {
+		var err error
+		failedWork, err = c.client.Perform(c.logger, work)
+		if err != nil {
+			c.logger.Error("failed-to-commit", err, lager.Data{"cell-guid": c.Guid})
+		}
+		return err
+	})
 	if err != nil {
-		c.logger.Error("failed-to-commit", err, lager.Data{"cell-guid": c.Guid})
+		if reconcile {
+			return c.reconcile(work)
+		}
 		//an error may indicate partial failure
 		//in this case we don't reschedule work in order to make sure we don't
 		//create duplicates of things -- we'll let the converger figure things out for us later
-		return rep.Work{}
+		return commitResult{}
+	}
+	return commitResult{failed: failedWork}
+}
+
+// reconcile reads the cell's state after a failed commit and sorts the work
+// into what the cell has and what it does not.  If the state cannot be read,
+// all of the work is unknown.
+func (c *Cell) reconcile(work rep.Work) commitResult {
+	state, err := c.client.State(c.logger)
+	if err != nil {
+		c.logger.Error("failed-to-reconcile", err, lager.Data{"cell-guid": c.Guid})
+		return commitResult{unknown: work}
+	}
+
+	placedLRPs := map[string]bool{}
+	for i := range state.LRPs {
+		placedLRPs[state.LRPs[i].Identifier()] = true
 	}
-	return failedWork
+	placedTasks := map[string]bool{}
+	for i := range state.Tasks {
+		placedTasks[state.Tasks[i].Identifier()] = true
+	}
+
+	result := commitResult{}
+	for i := range work.LRPs {
+		if !placedLRPs[work.LRPs[i].Identifier()] {
+			result.failed.LRPs = append(result.failed.LRPs, work.LRPs[i])
+		}
+	}
+	for i := range work.Tasks {
+		if !placedTasks[work.Tasks[i].Identifier()] {
+			result.failed.Tasks = append(result.failed.Tasks, work.Tasks[i])
+		}
+	}
+
+	c.logger.Info("reconciled", lager.Data{
+		"cell-guid":       c.Guid,
+		"missing-lrps":    len(result.failed.LRPs),
+		"missing-tasks":   len(result.failed.Tasks),
+		"committed-lrps":  len(work.LRPs),
+		"committed-tasks": len(work.Tasks),
+	})
+	return result
 }
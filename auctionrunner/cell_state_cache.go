@@ -0,0 +1,83 @@
+package auctionrunner
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/auction/auctiontypes"
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/rep"
+)
+
+// CellStateCache keeps the state fetched from each cell, so that building
+// zones only asks the cells whose state is missing or older than maxAge.  A
+// cell's entry is dropped when fetching its state fails, and should be dropped
+// with Invalidate whenever work is sent to or stopped on the cell.
+type CellStateCache struct {
+	clock  clock.Clock
+	maxAge time.Duration
+
+	lock   *sync.Mutex
+	states map[string]cachedCellState
+}
+
+type cachedCellState struct {
+	state     rep.CellState
+	fetchedAt time.Time
+}
+
+func NewCellStateCache(clock clock.Clock, maxAge time.Duration) *CellStateCache {
+	return &CellStateCache{
+		clock:  clock,
+		maxAge: maxAge,
+		lock:   &sync.Mutex{},
+		states: map[string]cachedCellState{},
+	}
+}
+
+// Invalidate drops the cached state of the given cells.
+func (c *CellStateCache) Invalidate(cellIDs ...string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, cellID := range cellIDs {
+		delete(c.states, cellID)
+	}
+}
+
+// InvalidatePlacements drops the cached state of every cell that was sent
+// work in the given results.
+func (c *CellStateCache) InvalidatePlacements(results auctiontypes.AuctionResults) {
+	cellIDs := []string{}
+	for _, lrpAuctions := range [][]auctiontypes.LRPAuction{results.SuccessfulLRPs, results.UnknownLRPs} {
+		for i := range lrpAuctions {
+			cellIDs = append(cellIDs, lrpAuctions[i].Winner)
+		}
+	}
+	for _, taskAuctions := range [][]auctiontypes.TaskAuction{results.SuccessfulTasks, results.UnknownTasks} {
+		for i := range taskAuctions {
+			cellIDs = append(cellIDs, taskAuctions[i].Winner)
+		}
+	}
+	c.Invalidate(cellIDs...)
+}
+
+// get returns a copy of the cell's cached state if it is young enough.
+func (c *CellStateCache) get(cellID string) (rep.CellState, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	cached, ok := c.states[cellID]
+	if !ok || c.clock.Since(cached.fetchedAt) >= c.maxAge {
+		return rep.CellState{}, false
+	}
+
+	return copyCellState(cached.state), true
+}
+
+func (c *CellStateCache) put(cellID string, state rep.CellState) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.states[cellID] = cachedCellState{state: state, fetchedAt: c.clock.Now()}
+}
@@ -0,0 +1,119 @@
+package auctionrunner
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/rep"
+)
+
+var errNoCellState = errors.New("no state known for cell")
+
+// CellStateSource provides the state of a cell when zones are built. The
+// client is the one the cell's work will be committed through; sources that
+// do not ask the cell itself may ignore it.
+type CellStateSource interface {
+	CellState(logger lager.Logger, cellID string, client rep.Client) (rep.CellState, error)
+}
+
+// PullCellStateSource asks each cell for its state.  It is the default.
+type PullCellStateSource struct{}
+
+func (PullCellStateSource) CellState(logger lager.Logger, _ string, client rep.Client) (rep.CellState, error) {
+	return client.State(logger)
+}
+
+// PushCellStateSource serves the states most recently published to it, by
+// the cells or by whatever watches them, so building zones makes no requests.
+type PushCellStateSource struct {
+	clock  clock.Clock
+	maxAge time.Duration
+
+	lock   *sync.RWMutex
+	states map[string]cachedCellState
+}
+
+// NewPushCellStateSource returns a source that serves published states until
+// they are older than maxAge.  A maxAge <= 0 means they never go stale.
+func NewPushCellStateSource(clock clock.Clock, maxAge time.Duration) *PushCellStateSource {
+	return &PushCellStateSource{
+		clock:  clock,
+		maxAge: maxAge,
+		lock:   &sync.RWMutex{},
+		states: map[string]cachedCellState{},
+	}
+}
+
+// Publish records the latest state of the cell it names.
+func (s *PushCellStateSource) Publish(state rep.CellState) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.states[state.CellID] = cachedCellState{state: state, fetchedAt: s.clock.Now()}
+}
+
+// Remove forgets the given cell, as when it goes away.
+func (s *PushCellStateSource) Remove(cellID string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.states, cellID)
+}
+
+func (s *PushCellStateSource) CellState(_ lager.Logger, cellID string, _ rep.Client) (rep.CellState, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	published, ok := s.states[cellID]
+	if !ok || (s.maxAge > 0 && s.clock.Since(published.fetchedAt) >= s.maxAge) {
+		return rep.CellState{}, errNoCellState
+	}
+	return copyCellState(published.state), nil
+}
+
+// SnapshotCellStateSource serves cell states read from a file, such as one
+// saved from a running system, to replay scheduling against.
+type SnapshotCellStateSource struct {
+	states map[string]rep.CellState
+}
+
+// NewSnapshotCellStateSource reads a JSON array of cell states from path.
+func NewSnapshotCellStateSource(path string) (*SnapshotCellStateSource, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []rep.CellState
+	err = json.Unmarshal(contents, &states)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SnapshotCellStateSource{states: map[string]rep.CellState{}}
+	for i := range states {
+		s.states[states[i].CellID] = states[i]
+	}
+	return s, nil
+}
+
+func (s *SnapshotCellStateSource) CellState(_ lager.Logger, cellID string, _ rep.Client) (rep.CellState, error) {
+	state, ok := s.states[cellID]
+	if !ok {
+		return rep.CellState{}, errNoCellState
+	}
+	return copyCellState(state), nil
+}
+
+// copyCellState copies the state's LRPs and Tasks, as a Cell adds to them
+// when work is reserved.
+func copyCellState(state rep.CellState) rep.CellState {
+	state.LRPs = append([]rep.LRP(nil), state.LRPs...)
+	state.Tasks = append([]rep.Task(nil), state.Tasks...)
+	return state
+}
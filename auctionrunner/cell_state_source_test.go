@@ -0,0 +1,137 @@
+package auctionrunner_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/auction/auctionrunner"
+	"code.cloudfoundry.org/auction/auctiontypes/fakes"
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/repfakes"
+	"code.cloudfoundry.org/workpool"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CellStateSource", func() {
+	var (
+		clock  *fakeclock.FakeClock
+		client *repfakes.FakeSimClient
+		stateA rep.CellState
+		stateB rep.CellState
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		client = &repfakes.FakeSimClient{}
+		stateA = BuildCellState("A", 0, "the-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, nil, []string{}, []string{}, []string{}, 0)
+		stateB = BuildCellState("B", 1, "the-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, nil, []string{}, []string{}, []string{}, 0)
+	})
+
+	Describe("PullCellStateSource", func() {
+		It("asks the cell", func() {
+			client.StateReturns(stateA, nil)
+
+			state, err := auctionrunner.PullCellStateSource{}.CellState(logger, "A", client)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state).To(Equal(stateA))
+			Expect(client.StateCallCount()).To(Equal(1))
+		})
+	})
+
+	Describe("PushCellStateSource", func() {
+		var source *auctionrunner.PushCellStateSource
+
+		BeforeEach(func() {
+			source = auctionrunner.NewPushCellStateSource(clock, time.Minute)
+			source.Publish(stateA)
+		})
+
+		It("serves the latest published state without asking the cell", func() {
+			clock.Increment(30 * time.Second)
+			stateA.StartingContainerCount = 3
+			source.Publish(stateA)
+
+			state, err := source.CellState(logger, "A", client)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.StartingContainerCount).To(Equal(3))
+			Expect(client.StateCallCount()).To(BeZero())
+		})
+
+		It("fails for cells that have published nothing", func() {
+			_, err := source.CellState(logger, "B", client)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("fails once the state is older than the maximum age", func() {
+			clock.Increment(time.Minute)
+			_, err := source.CellState(logger, "A", client)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("fails for removed cells", func() {
+			source.Remove("A")
+			_, err := source.CellState(logger, "A", client)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("does not let work reserved on a cell change the published state", func() {
+			state, err := source.CellState(logger, "A", client)
+			Expect(err).NotTo(HaveOccurred())
+			cell := auctionrunner.NewCell(logger, "A", client, state)
+			Expect(cell.ReserveLRP(BuildLRP("pg-1", "domain", 0, linuxRootFSURL, 10, 10, 10, []string{}))).To(Succeed())
+
+			state, err = source.CellState(logger, "A", client)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.LRPs).To(BeEmpty())
+		})
+
+		It("is used to build zones", func() {
+			source.Publish(stateB)
+
+			workPool, err := workpool.NewWorkPool(5)
+			Expect(err).NotTo(HaveOccurred())
+			defer workPool.Stop()
+
+			clients := map[string]rep.Client{"A": client, "B": client, "C": client}
+			zones := auctionrunner.FetchStateAndBuildZonesWithRetry(logger, clock, auctionrunner.RetryPolicy{}, source, nil, workPool, clients, &fakes.FakeAuctionMetricEmitterDelegate{}, 0)
+			Expect(zones["the-zone"]).To(HaveLen(2))
+			Expect(client.StateCallCount()).To(BeZero())
+		})
+	})
+
+	Describe("SnapshotCellStateSource", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "snapshot.json")
+			contents, err := json.Marshal([]rep.CellState{stateA, stateB})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(path, contents, 0600)).To(Succeed())
+		})
+
+		It("serves the states in the snapshot", func() {
+			source, err := auctionrunner.NewSnapshotCellStateSource(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			state, err := source.CellState(logger, "B", client)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.CellID).To(Equal("B"))
+			Expect(state.AvailableResources).To(Equal(stateB.AvailableResources))
+
+			_, err = source.CellState(logger, "C", client)
+			Expect(err).To(HaveOccurred())
+			Expect(client.StateCallCount()).To(BeZero())
+		})
+
+		It("fails to read a snapshot that is not valid", func() {
+			Expect(os.WriteFile(path, []byte("not json"), 0600)).To(Succeed())
+			_, err := auctionrunner.NewSnapshotCellStateSource(path)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -622,6 +622,18 @@ var _ = Describe("Cell", func() {
 				Expect(work).To(Equal(rep.Work{LRPs: []rep.LRP{lrp}, CellID: cell.Guid}))
 			})
 
+			It("only sends work reserved since the last commit", func() {
+				cell.Commit()
+
+				task := BuildTask("tg-new", "domain", linuxRootFSURL, 10, 10, 10, []string{}, []string{})
+				Expect(cell.ReserveTask(task)).To(Succeed())
+				cell.Commit()
+
+				Expect(client.PerformCallCount()).To(Equal(2))
+				_, work := client.PerformArgsForCall(1)
+				Expect(work).To(Equal(rep.Work{Tasks: []rep.Task{*task}, CellID: cell.Guid}))
+			})
+
 			Context("when the client returns some failed work", func() {
 				It("forwards the failed work", func() {
 					failedWork := rep.Work{
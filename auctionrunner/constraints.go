@@ -0,0 +1,13 @@
+package auctionrunner
+
+import (
+	"code.cloudfoundry.org/auction/auctiontypes"
+	"code.cloudfoundry.org/auctioneer"
+)
+
+// ConstraintProvider gives work its placement constraints as it is
+// submitted.  Every instance of a start request shares its constraints.
+type ConstraintProvider interface {
+	LRPConstraints(start *auctioneer.LRPStartRequest) auctiontypes.LRPConstraints
+	TaskConstraints(task *auctioneer.TaskStartRequest) auctiontypes.TaskConstraints
+}
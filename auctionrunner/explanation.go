@@ -0,0 +1,105 @@
+package auctionrunner
+
+import (
+	"sort"
+
+	"code.cloudfoundry.org/auction/auctiontypes"
+	"code.cloudfoundry.org/rep"
+)
+
+const (
+	reasonZoneHasMoreInstances = "not considered: a zone with fewer instances had a suitable cell"
+	reasonZoneHasMoreTasks     = "not considered: a zone with fewer tasks in the group had a suitable cell"
+)
+
+// explanation collects what happened to each candidate cell during a single
+// placement.  A nil explanation ignores everything, so callers don't need to
+// check whether explanations were asked for.
+type explanation struct {
+	cells []auctiontypes.CellExplanation
+}
+
+func (s *Scheduler) newExplanation() *explanation {
+	if !s.explainPlacements {
+		return nil
+	}
+	return &explanation{}
+}
+
+func (e *explanation) rejectedByFilters(rejections []CellRejection) {
+	if e == nil {
+		return
+	}
+
+	for _, rejection := range rejections {
+		e.cells = append(e.cells, auctiontypes.CellExplanation{
+			CellID: rejection.CellGuid,
+			Zone:   rejection.Zone,
+			Filter: rejection.Filter,
+			Reason: rejection.Reason.Error(),
+		})
+	}
+}
+
+func (e *explanation) rejectedByScorer(cell *Cell, err error) {
+	if e == nil {
+		return
+	}
+
+	cellExplanation := auctiontypes.CellExplanation{
+		CellID: cell.Guid,
+		Zone:   cell.state.Zone,
+		Reason: err.Error(),
+	}
+
+	if ierr, ok := err.(rep.InsufficientResourcesError); ok {
+		for problem := range ierr.Problems {
+			cellExplanation.MissingResources = append(cellExplanation.MissingResources, problem)
+		}
+		sort.Strings(cellExplanation.MissingResources)
+	}
+
+	e.cells = append(e.cells, cellExplanation)
+}
+
+func (e *explanation) scored(cell *Cell, score auctiontypes.ScoreBreakdown) {
+	if e == nil {
+		return
+	}
+
+	e.cells = append(e.cells, auctiontypes.CellExplanation{
+		CellID: cell.Guid,
+		Zone:   cell.state.Zone,
+		Score:  &score,
+	})
+}
+
+func (e *explanation) notConsidered(zone Zone, reason string) {
+	if e == nil {
+		return
+	}
+
+	for _, cell := range zone {
+		e.cells = append(e.cells, auctiontypes.CellExplanation{
+			CellID: cell.Guid,
+			Zone:   cell.state.Zone,
+			Reason: reason,
+		})
+	}
+}
+
+func (e *explanation) placementExplanation(winner *Cell) *auctiontypes.PlacementExplanation {
+	if e == nil {
+		return nil
+	}
+
+	if winner != nil {
+		for i := range e.cells {
+			if e.cells[i].CellID == winner.Guid {
+				e.cells[i].Winner = true
+			}
+		}
+	}
+
+	return &auctiontypes.PlacementExplanation{Cells: e.cells}
+}
@@ -0,0 +1,135 @@
+package auctionrunner
+
+import (
+	"strings"
+)
+
+const reasonDomainHasMoreInstances = "not considered: a failure domain with fewer instances had a suitable cell"
+
+// FailureDomainProvider places cells in a hierarchy of failure domains, such
+// as region, zone, rack and power domain.  It returns the domains holding a
+// cell, outermost first.  LRP instances are spread level by level: first
+// across the outermost domains, then across the domains within them, and so
+// on.
+type FailureDomainProvider interface {
+	FailureDomains(cell *Cell) []string
+}
+
+// ZoneFailureDomains places each cell in its zone alone, which is how LRPs
+// are spread without a FailureDomainProvider.
+type ZoneFailureDomains struct{}
+
+func (ZoneFailureDomains) FailureDomains(cell *Cell) []string {
+	return []string{cell.state.Zone}
+}
+
+// PlacementTagFailureDomains reads failure domains from cell placement tags
+// of the form "<level>:<domain>", such as "rack:r12".  Levels names the
+// levels, outermost first.  A cell without a tag for a level is in the empty
+// domain at that level.
+type PlacementTagFailureDomains struct {
+	Levels []string
+}
+
+func (p PlacementTagFailureDomains) FailureDomains(cell *Cell) []string {
+	domains := make([]string, len(p.Levels))
+	for i, level := range p.Levels {
+		prefix := level + ":"
+		for _, tag := range cell.state.PlacementTags {
+			if strings.HasPrefix(tag, prefix) {
+				domains[i] = strings.TrimPrefix(tag, prefix)
+				break
+			}
+		}
+		if domains[i] != "" {
+			continue
+		}
+		for _, tag := range cell.state.OptionalPlacementTags {
+			if strings.HasPrefix(tag, prefix) {
+				domains[i] = strings.TrimPrefix(tag, prefix)
+				break
+			}
+		}
+	}
+	return domains
+}
+
+// splitByFailureDomain divides each of the zones into groups of cells in the
+// same failure domains, and gives every group the LRP's instances in each of
+// its domains.  The instances are counted across allZones, so that cells the
+// auction cannot use still count towards their domains.
+//
+// Zones a spread constraint has pooled stay ahead of the rest and alike one
+// another, so their groups are ordered only by the domains below the zone:
+// those within a single zone that do not hold all of its cells.  Cells in
+// no such domain are ordered as though their domains held no instances.
+func splitByFailureDomain(zones []lrpByZone, allZones map[string]Zone, provider FailureDomainProvider, processGuid string) []lrpByZone {
+	domainsOf := map[*Cell][]string{}
+	instances := map[string]int{}
+	cellsIn := map[string]int{}
+	zonesOf := map[string]map[string]struct{}{}
+	cellsInZone := map[string]int{}
+	for _, zone := range allZones {
+		for _, cell := range zone {
+			domains := provider.FailureDomains(cell)
+			domainsOf[cell] = domains
+			count := instancesOf(cell, processGuid)
+			cellsInZone[cell.state.Zone]++
+			for level := range domains {
+				key := domainKey(domains[:level+1])
+				instances[key] += count
+				cellsIn[key]++
+				if zonesOf[key] == nil {
+					zonesOf[key] = map[string]struct{}{}
+				}
+				zonesOf[key][cell.state.Zone] = struct{}{}
+			}
+		}
+	}
+
+	belowZone := func(key, zone string) bool {
+		_, inZone := zonesOf[key][zone]
+		return inZone && len(zonesOf[key]) == 1 && cellsIn[key] < cellsInZone[zone]
+	}
+
+	groups := []lrpByZone{}
+	for _, lrpZone := range zones {
+		pooled := lrpZone.instances < 0
+
+		indexes := map[string]int{}
+		for _, cell := range lrpZone.zone {
+			domains, ok := domainsOf[cell]
+			if !ok {
+				domains = provider.FailureDomains(cell)
+			}
+			key := domainKey(domains)
+			index, ok := indexes[key]
+			if !ok {
+				domainInstances := make([]int, len(domains))
+				for level := range domains {
+					levelKey := domainKey(domains[:level+1])
+					if pooled && !belowZone(levelKey, cell.state.Zone) {
+						continue
+					}
+					domainInstances[level] = instances[levelKey]
+				}
+				if pooled {
+					domainInstances = append([]int{lrpZone.instances}, domainInstances...)
+				}
+				index = len(groups)
+				indexes[key] = index
+				groups = append(groups, lrpByZone{
+					instances:       lrpZone.instances,
+					domainInstances: domainInstances,
+				})
+			}
+			groups[index].zone = append(groups[index].zone, cell)
+		}
+	}
+
+	return groups
+}
+
+func domainKey(domains []string) string {
+	return strings.Join(domains, "\x00")
+}
@@ -0,0 +1,34 @@
+package auctionrunner_test
+
+import (
+	"code.cloudfoundry.org/auction/auctionrunner"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/repfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PlacementTagFailureDomains", func() {
+	var provider auctionrunner.PlacementTagFailureDomains
+
+	BeforeEach(func() {
+		provider = auctionrunner.PlacementTagFailureDomains{Levels: []string{"region", "rack", "power"}}
+	})
+
+	newCell := func(placementTags, optionalPlacementTags []string) *auctionrunner.Cell {
+		state := BuildCellState("cellID", 0, "zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{}, []string{}, placementTags, optionalPlacementTags, 0)
+		return auctionrunner.NewCell(lagertest.NewTestLogger("test"), "cell", &repfakes.FakeSimClient{}, state)
+	}
+
+	It("reads each level from the cell's placement tags, outermost first", func() {
+		cell := newCell([]string{"rack:r1", "region:east"}, []string{"power:p2"})
+		Expect(provider.FailureDomains(cell)).To(Equal([]string{"east", "r1", "p2"}))
+	})
+
+	It("puts a cell without a tag for a level in the empty domain", func() {
+		cell := newCell([]string{"region:east"}, []string{"racks:r1"})
+		Expect(provider.FailureDomains(cell)).To(Equal([]string{"east", "", ""}))
+	})
+})
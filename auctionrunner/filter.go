@@ -0,0 +1,182 @@
+package auctionrunner
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/auction/auctiontypes"
+	"code.cloudfoundry.org/rep"
+)
+
+// PlacementFilter decides whether a cell may run a piece of work. Returning
+// an error rejects the cell, and the error is the reason reported back to the
+// submitter when no cell is left.
+type PlacementFilter interface {
+	Name() string
+	FilterLRP(cell *Cell, lrp *rep.LRP) error
+	FilterTask(cell *Cell, task *rep.Task) error
+}
+
+// CellRejection records which filter turned a cell away, and why.
+type CellRejection struct {
+	CellGuid string
+	Zone     string
+	Filter   string
+	Reason   error
+
+	// position of the filter in the chain; later filters are more specific
+	stage int
+}
+
+// DefaultPlacementFilters returns the filters every auction applies, from
+// least to most specific.
+func DefaultPlacementFilters() []PlacementFilter {
+	return []PlacementFilter{
+		RootFSFilter{},
+		VolumeDriverFilter{},
+		PlacementTagFilter{},
+	}
+}
+
+type RootFSFilter struct{}
+
+func (RootFSFilter) Name() string { return "rootfs" }
+
+func (f RootFSFilter) FilterLRP(cell *Cell, lrp *rep.LRP) error {
+	return f.filter(cell, lrp.PlacementConstraint)
+}
+
+func (f RootFSFilter) FilterTask(cell *Cell, task *rep.Task) error {
+	return f.filter(cell, task.PlacementConstraint)
+}
+
+func (RootFSFilter) filter(cell *Cell, pc rep.PlacementConstraint) error {
+	if !cell.MatchRootFS(pc.RootFs) {
+		return auctiontypes.ErrorCellMismatch
+	}
+	return nil
+}
+
+type VolumeDriverFilter struct{}
+
+func (VolumeDriverFilter) Name() string { return "volume-drivers" }
+
+func (f VolumeDriverFilter) FilterLRP(cell *Cell, lrp *rep.LRP) error {
+	return f.filter(cell, lrp.PlacementConstraint)
+}
+
+func (f VolumeDriverFilter) FilterTask(cell *Cell, task *rep.Task) error {
+	return f.filter(cell, task.PlacementConstraint)
+}
+
+func (VolumeDriverFilter) filter(cell *Cell, pc rep.PlacementConstraint) error {
+	if !cell.MatchVolumeDrivers(pc.VolumeDrivers) {
+		return auctiontypes.ErrorVolumeDriverMismatch
+	}
+	return nil
+}
+
+type PlacementTagFilter struct{}
+
+func (PlacementTagFilter) Name() string { return "placement-tags" }
+
+func (f PlacementTagFilter) FilterLRP(cell *Cell, lrp *rep.LRP) error {
+	return f.filter(cell, lrp.PlacementConstraint)
+}
+
+func (f PlacementTagFilter) FilterTask(cell *Cell, task *rep.Task) error {
+	return f.filter(cell, task.PlacementConstraint)
+}
+
+func (PlacementTagFilter) filter(cell *Cell, pc rep.PlacementConstraint) error {
+	if !cell.MatchPlacementTags(pc.PlacementTags) {
+		return auctiontypes.NewPlacementTagMismatchError(pc.PlacementTags)
+	}
+	return nil
+}
+
+var errRejectedAtCommit = errors.New("cell refused the work when it was committed")
+
+// rejectedAtCommitFilter keeps work away from the cell that refused it, for
+// second-chance placement.  It maps each auction's identifier to the guid of
+// the cell that refused it.
+type rejectedAtCommitFilter struct {
+	lrps  map[string]string
+	tasks map[string]string
+}
+
+func (rejectedAtCommitFilter) Name() string { return "rejected-at-commit" }
+
+func (f rejectedAtCommitFilter) FilterLRP(cell *Cell, lrp *rep.LRP) error {
+	if f.lrps[lrp.Identifier()] == cell.Guid {
+		return errRejectedAtCommit
+	}
+	return nil
+}
+
+func (f rejectedAtCommitFilter) FilterTask(cell *Cell, task *rep.Task) error {
+	if f.tasks[task.Identifier()] == cell.Guid {
+		return errRejectedAtCommit
+	}
+	return nil
+}
+
+type placementFilters []PlacementFilter
+
+func (filters placementFilters) filterLRPCells(zone Zone, lrp *rep.LRP) ([]*Cell, []CellRejection) {
+	return filters.filterCells(zone, func(filter PlacementFilter, cell *Cell) error {
+		return filter.FilterLRP(cell, lrp)
+	})
+}
+
+func (filters placementFilters) filterTaskCells(zone Zone, task *rep.Task) ([]*Cell, []CellRejection) {
+	return filters.filterCells(zone, func(filter PlacementFilter, cell *Cell) error {
+		return filter.FilterTask(cell, task)
+	})
+}
+
+func (filters placementFilters) filterCells(zone Zone, check func(PlacementFilter, *Cell) error) ([]*Cell, []CellRejection) {
+	cells := make([]*Cell, 0, len(zone))
+	rejections := []CellRejection{}
+
+	for _, cell := range zone {
+		rejection := filters.check(cell, check)
+		if rejection != nil {
+			rejections = append(rejections, *rejection)
+			continue
+		}
+		cells = append(cells, cell)
+	}
+
+	return cells, rejections
+}
+
+func (filters placementFilters) check(cell *Cell, check func(PlacementFilter, *Cell) error) *CellRejection {
+	for stage, filter := range filters {
+		if err := check(filter, cell); err != nil {
+			return &CellRejection{
+				CellGuid: cell.Guid,
+				Zone:     cell.state.Zone,
+				Filter:   filter.Name(),
+				Reason:   err,
+				stage:    stage,
+			}
+		}
+	}
+	return nil
+}
+
+// mostSpecificRejection picks the reason to report when every cell was
+// rejected: the one from the cell that made it furthest through the chain.
+func mostSpecificRejection(rejections []CellRejection) error {
+	var best *CellRejection
+	for i := range rejections {
+		if best == nil || rejections[i].stage > best.stage {
+			best = &rejections[i]
+		}
+	}
+
+	if best == nil {
+		return auctiontypes.ErrorCellMismatch
+	}
+	return best.Reason
+}
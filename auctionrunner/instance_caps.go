@@ -0,0 +1,79 @@
+package auctionrunner
+
+import (
+	"code.cloudfoundry.org/auction/auctiontypes"
+	"code.cloudfoundry.org/rep"
+)
+
+// instanceCapFilter rejects the cells where another instance of an LRP would
+// go over its caps.  zoneInstances holds the LRP's instances in each zone.
+type instanceCapFilter struct {
+	perCell       int
+	perZone       int
+	zoneInstances map[string]int
+}
+
+func (instanceCapFilter) Name() string { return "instance-caps" }
+
+func (f instanceCapFilter) FilterLRP(cell *Cell, lrp *rep.LRP) error {
+	if f.perCell > 0 && instancesOf(cell, lrp.ProcessGuid) >= f.perCell {
+		return auctiontypes.InstanceCapError{Scope: "cell", Max: f.perCell}
+	}
+	if f.perZone > 0 && f.zoneInstances[cell.state.Zone] >= f.perZone {
+		return auctiontypes.InstanceCapError{Scope: "zone", Max: f.perZone}
+	}
+	return nil
+}
+
+func (instanceCapFilter) FilterTask(cell *Cell, task *rep.Task) error {
+	return nil
+}
+
+// withInstanceCaps returns the filters to place an LRP with the given
+// constraints, counting its instances across zones.
+func (filters placementFilters) withInstanceCaps(zones map[string]Zone, processGuid string, constraints auctiontypes.LRPConstraints) placementFilters {
+	if constraints.MaxInstancesPerCell <= 0 && constraints.MaxInstancesPerZone <= 0 {
+		return filters
+	}
+
+	filter := instanceCapFilter{
+		perCell:       constraints.MaxInstancesPerCell,
+		perZone:       constraints.MaxInstancesPerZone,
+		zoneInstances: map[string]int{},
+	}
+	if filter.perZone > 0 {
+		for _, zone := range zones {
+			for _, cell := range zone {
+				filter.zoneInstances[cell.state.Zone] += instancesOf(cell, processGuid)
+			}
+		}
+	}
+	return append(filters[:len(filters):len(filters)], filter)
+}
+
+// capBlockedPlacement returns the cap that kept an LRP off a cell with room
+// for it, if any.
+func (s *Scheduler) capBlockedPlacement(rejections []CellRejection, lrp *rep.LRP) error {
+	capped := map[string]error{}
+	for _, rejection := range rejections {
+		if _, ok := rejection.Reason.(auctiontypes.InstanceCapError); ok {
+			capped[rejection.CellGuid] = rejection.Reason
+		}
+	}
+	if len(capped) == 0 {
+		return nil
+	}
+
+	for _, zone := range s.zones {
+		for _, cell := range zone {
+			capErr, ok := capped[cell.Guid]
+			if !ok {
+				continue
+			}
+			if _, err := s.scoreLRP(cell, lrp); err == nil {
+				return capErr
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package auctionrunner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"code.cloudfoundry.org/auction/auctiontypes"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+const (
+	journalAddLRPs    = "add-lrps"
+	journalAddTasks   = "add-tasks"
+	journalCancelLRP  = "cancel-lrp"
+	journalCancelTask = "cancel-task"
+)
+
+var errJournalNotOpen = errors.New("journal file is not open")
+
+type journalEntry struct {
+	Op          string                     `json:"op"`
+	LRPs        []auctiontypes.LRPAuction  `json:"lrps,omitempty"`
+	Tasks       []auctiontypes.TaskAuction `json:"tasks,omitempty"`
+	ProcessGuid string                     `json:"process_guid,omitempty"`
+	Index       int                        `json:"index,omitempty"`
+	TaskGuid    string                     `json:"task_guid,omitempty"`
+}
+
+// Journal is an append-only file recording what is waiting in a Batch, so
+// that pending auctions survive a restart.  Each change to the batch is
+// appended as a line of JSON; after every drain the file is rewritten to
+// hold only what is still pending.
+type Journal struct {
+	logger lager.Logger
+	path   string
+
+	lock *sync.Mutex
+	file *os.File
+
+	lrpAuctions  []auctiontypes.LRPAuction
+	taskAuctions []auctiontypes.TaskAuction
+}
+
+// NewJournal opens the journal at path, creating it if it does not exist,
+// and replays it to recover the auctions that were pending when it was last
+// written.  A truncated final line, left by a crash mid-write, is ignored.
+func NewJournal(logger lager.Logger, path string) (*Journal, error) {
+	j := &Journal{
+		logger: logger.Session("journal", lager.Data{"path": path}),
+		path:   path,
+		lock:   &sync.Mutex{},
+	}
+
+	err := j.replay()
+	if err != nil {
+		return nil, err
+	}
+
+	// rewrite straight away so a torn line is not left behind for later
+	// appends to land after
+	err = j.compact(j.lrpAuctions, j.taskAuctions)
+	if err != nil {
+		return nil, err
+	}
+
+	j.logger.Info("restored", lager.Data{
+		"lrp-start-auctions": len(j.lrpAuctions),
+		"task-auctions":      len(j.taskAuctions),
+	})
+
+	return j, nil
+}
+
+// Pending returns the auctions recovered when the journal was opened.
+func (j *Journal) Pending() ([]auctiontypes.LRPAuction, []auctiontypes.TaskAuction) {
+	return j.lrpAuctions, j.taskAuctions
+}
+
+func (j *Journal) Close() error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+func (j *Journal) appendLRPs(auctions []auctiontypes.LRPAuction) error {
+	return j.append(journalEntry{Op: journalAddLRPs, LRPs: auctions})
+}
+
+func (j *Journal) appendTasks(auctions []auctiontypes.TaskAuction) error {
+	return j.append(journalEntry{Op: journalAddTasks, Tasks: auctions})
+}
+
+func (j *Journal) appendLRPCancel(processGuid string, index int) error {
+	return j.append(journalEntry{Op: journalCancelLRP, ProcessGuid: processGuid, Index: index})
+}
+
+func (j *Journal) appendTaskCancel(taskGuid string) error {
+	return j.append(journalEntry{Op: journalCancelTask, TaskGuid: taskGuid})
+}
+
+func (j *Journal) append(entry journalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.file == nil {
+		j.logger.Error("failed-to-append", errJournalNotOpen, lager.Data{"op": entry.Op})
+		return errJournalNotOpen
+	}
+
+	_, err = j.file.Write(append(line, '\n'))
+	if err != nil {
+		j.logger.Error("failed-to-append", err, lager.Data{"op": entry.Op})
+		return err
+	}
+
+	return j.file.Sync()
+}
+
+// compact replaces the journal with one holding just the given auctions.
+// The new contents are written to a temporary file and renamed into place,
+// so a crash leaves either the old journal or the new one.
+func (j *Journal) compact(lrpAuctions []auctiontypes.LRPAuction, taskAuctions []auctiontypes.TaskAuction) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".tmp")
+	if err != nil {
+		j.logger.Error("failed-to-compact", err)
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = writeJournalEntries(tmp, lrpAuctions, taskAuctions)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), j.path)
+	}
+	if err != nil {
+		j.logger.Error("failed-to-compact", err)
+		return err
+	}
+
+	// the old file is no longer on disk, so nothing more may be written to it
+	if j.file != nil {
+		j.file.Close()
+		j.file = nil
+	}
+
+	file, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		j.logger.Error("failed-to-reopen", err)
+		return err
+	}
+	j.file = file
+
+	return nil
+}
+
+func writeJournalEntries(w io.Writer, lrpAuctions []auctiontypes.LRPAuction, taskAuctions []auctiontypes.TaskAuction) error {
+	encoder := json.NewEncoder(w)
+
+	if len(lrpAuctions) > 0 {
+		err := encoder.Encode(journalEntry{Op: journalAddLRPs, LRPs: lrpAuctions})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(taskAuctions) > 0 {
+		err := encoder.Encode(journalEntry{Op: journalAddTasks, Tasks: taskAuctions})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (j *Journal) replay() error {
+	file, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	batch := &Batch{}
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				j.logger.Info("ignoring-truncated-entry")
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var entry journalEntry
+		err = json.Unmarshal(line, &entry)
+		if err != nil {
+			return err
+		}
+
+		switch entry.Op {
+		case journalAddLRPs:
+			batch.lrpAuctions = append(batch.lrpAuctions, entry.LRPs...)
+		case journalAddTasks:
+			batch.taskAuctions = append(batch.taskAuctions, entry.Tasks...)
+		case journalCancelLRP:
+			batch.removeLRPStart(entry.ProcessGuid, entry.Index)
+		case journalCancelTask:
+			batch.removeTask(entry.TaskGuid)
+		}
+	}
+
+	j.lrpAuctions = batch.lrpAuctions
+	j.taskAuctions = batch.taskAuctions
+	return nil
+}
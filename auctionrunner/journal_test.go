@@ -0,0 +1,198 @@
+package auctionrunner_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/auction/auctionrunner"
+	"code.cloudfoundry.org/auctioneer"
+	"code.cloudfoundry.org/clock/fakeclock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Journal", func() {
+	var (
+		clock   *fakeclock.FakeClock
+		path    string
+		journal *auctionrunner.Journal
+		batch   *auctionrunner.Batch
+	)
+
+	restart := func() {
+		Expect(journal.Close()).To(Succeed())
+
+		var err error
+		journal, err = auctionrunner.NewJournal(logger, path)
+		Expect(err).NotTo(HaveOccurred())
+		batch = auctionrunner.NewBatch(clock, auctionrunner.WithBatchJournal(journal))
+	}
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		path = filepath.Join(GinkgoT().TempDir(), "journal")
+
+		var err error
+		journal, err = auctionrunner.NewJournal(logger, path)
+		Expect(err).NotTo(HaveOccurred())
+		batch = auctionrunner.NewBatch(clock, auctionrunner.WithBatchJournal(journal))
+	})
+
+	AfterEach(func() {
+		journal.Close()
+	})
+
+	It("starts off empty", func() {
+		lrpAuctions, taskAuctions := journal.Pending()
+		Expect(lrpAuctions).To(BeEmpty())
+		Expect(taskAuctions).To(BeEmpty())
+		Expect(batch.HasWork).NotTo(Receive())
+	})
+
+	It("restores pending auctions, with their queue times, after a restart", func() {
+		queueTime := clock.Now()
+		err := batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+			BuildLRPStartRequest("pg-1", "domain", []int{0, 1}, linuxRootFSURL, 10, 10, 10, []string{}, []string{}),
+		}, "some-trace-id")
+		Expect(err).NotTo(HaveOccurred())
+
+		clock.Increment(time.Minute)
+		err = batch.AddTasks([]auctioneer.TaskStartRequest{
+			BuildTaskStartRequest("tg-1", "domain", linuxRootFSURL, 10, 10, 10),
+		}, "some-trace-id")
+		Expect(err).NotTo(HaveOccurred())
+
+		restart()
+
+		Expect(batch.HasWork).To(Receive())
+		lrpAuctions, taskAuctions := batch.DedupeAndDrain()
+		Expect(lrpAuctions).To(HaveLen(2))
+		Expect(lrpAuctions[0].ProcessGuid).To(Equal("pg-1"))
+		Expect(lrpAuctions[1].Index).To(BeEquivalentTo(1))
+		Expect(lrpAuctions[0].QueueTime).To(BeTemporally("==", queueTime))
+		Expect(taskAuctions).To(HaveLen(1))
+		Expect(taskAuctions[0].TaskGuid).To(Equal("tg-1"))
+		Expect(taskAuctions[0].QueueTime).To(BeTemporally("==", queueTime.Add(time.Minute)))
+	})
+
+	It("does not restore cancelled auctions", func() {
+		err := batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+			BuildLRPStartRequest("pg-1", "domain", []int{0, 1}, linuxRootFSURL, 10, 10, 10, []string{}, []string{}),
+		}, "some-trace-id")
+		Expect(err).NotTo(HaveOccurred())
+		err = batch.AddTasks([]auctioneer.TaskStartRequest{
+			BuildTaskStartRequest("tg-1", "domain", linuxRootFSURL, 10, 10, 10),
+		}, "some-trace-id")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(batch.CancelLRPStart("pg-1", 0)).To(Succeed())
+		Expect(batch.CancelTask("tg-1")).To(Succeed())
+
+		restart()
+
+		lrpAuctions, taskAuctions := batch.DedupeAndDrain()
+		Expect(lrpAuctions).To(HaveLen(1))
+		Expect(lrpAuctions[0].Index).To(BeEquivalentTo(1))
+		Expect(taskAuctions).To(BeEmpty())
+	})
+
+	It("only keeps what is still pending after a drain", func() {
+		err := batch.AddTasks([]auctioneer.TaskStartRequest{
+			BuildTaskStartRequest("tg-1", "domain", linuxRootFSURL, 10, 10, 10),
+			BuildTaskStartRequest("tg-2", "domain", linuxRootFSURL, 10, 10, 10),
+		}, "some-trace-id")
+		Expect(err).NotTo(HaveOccurred())
+
+		batch.DedupeAndDrainUpTo(1)
+
+		restart()
+
+		_, taskAuctions := batch.DedupeAndDrain()
+		Expect(taskAuctions).To(HaveLen(1))
+		Expect(taskAuctions[0].TaskGuid).To(Equal("tg-2"))
+	})
+
+	It("keeps requeued auctions, with their attempts", func() {
+		err := batch.AddTasks([]auctioneer.TaskStartRequest{
+			BuildTaskStartRequest("tg-1", "domain", linuxRootFSURL, 10, 10, 10),
+		}, "some-trace-id")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, taskAuctions := batch.DedupeAndDrain()
+		taskAuctions[0].Attempts = 2
+		batch.Requeue(nil, taskAuctions)
+
+		restart()
+
+		_, taskAuctions = batch.DedupeAndDrain()
+		Expect(taskAuctions).To(HaveLen(1))
+		Expect(taskAuctions[0].TaskGuid).To(Equal("tg-1"))
+		Expect(taskAuctions[0].Attempts).To(Equal(2))
+	})
+
+	It("restores the number of attempts made", func() {
+		Expect(journal.Close()).To(Succeed())
+		err := os.WriteFile(path, []byte(`{"op":"add-tasks","tasks":[{"TaskGuid":"tg-1","Attempts":3}]}`+"\n"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+
+		journal, err = auctionrunner.NewJournal(logger, path)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, taskAuctions := journal.Pending()
+		Expect(taskAuctions).To(HaveLen(1))
+		Expect(taskAuctions[0].Attempts).To(Equal(3))
+	})
+
+	It("ignores an entry left half written by a crash", func() {
+		err := batch.AddTasks([]auctioneer.TaskStartRequest{
+			BuildTaskStartRequest("tg-1", "domain", linuxRootFSURL, 10, 10, 10),
+		}, "some-trace-id")
+		Expect(err).NotTo(HaveOccurred())
+
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = file.WriteString(`{"op":"add-tasks","tasks":[{"TaskGu`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file.Close()).To(Succeed())
+
+		restart()
+
+		err = batch.AddTasks([]auctioneer.TaskStartRequest{
+			BuildTaskStartRequest("tg-2", "domain", linuxRootFSURL, 10, 10, 10),
+		}, "some-trace-id")
+		Expect(err).NotTo(HaveOccurred())
+
+		restart()
+
+		_, taskAuctions := batch.DedupeAndDrain()
+		Expect(taskAuctions).To(HaveLen(2))
+		Expect(taskAuctions[0].TaskGuid).To(Equal("tg-1"))
+		Expect(taskAuctions[1].TaskGuid).To(Equal("tg-2"))
+	})
+
+	It("refuses work once it is closed", func() {
+		Expect(journal.Close()).To(Succeed())
+
+		err := batch.AddLRPStarts([]auctioneer.LRPStartRequest{
+			BuildLRPStartRequest("pg-1", "domain", []int{0}, linuxRootFSURL, 10, 10, 10, []string{}, []string{}),
+		}, "some-trace-id")
+		Expect(err).To(HaveOccurred())
+
+		lrpAuctions, _ := batch.DedupeAndDrain()
+		Expect(lrpAuctions).To(BeEmpty())
+	})
+
+	It("fails to open a journal that is corrupt", func() {
+		Expect(journal.Close()).To(Succeed())
+		err := os.WriteFile(path, []byte("not json\n"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = auctionrunner.NewJournal(logger, path)
+		Expect(err).To(HaveOccurred())
+
+		journal, err = auctionrunner.NewJournal(logger, filepath.Join(filepath.Dir(path), "other"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,15 @@
+package auctionrunner
+
+import (
+	"code.cloudfoundry.org/auctioneer"
+)
+
+// PriorityClassifier gives work its priority class as it is submitted.
+// Higher priorities are auctioned first, so that when capacity is short it
+// goes to them.  Within a priority, the first instances of LRPs are still
+// auctioned ahead of tasks, and tasks ahead of later instances.  Work is
+// given priority 0 when there is no classifier.
+type PriorityClassifier interface {
+	LRPPriority(start *auctioneer.LRPStartRequest) int
+	TaskPriority(task *auctioneer.TaskStartRequest) int
+}
@@ -0,0 +1,153 @@
+package auctionrunner
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/auction/auctiontypes"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/rep"
+)
+
+// inflightReservations remembers the work rounds placed on cells until the
+// cells' state shows it.  A cell can take a while to report work it has
+// been sent, and a round working from such state would otherwise place more
+// work on capacity that is already spoken for.
+type inflightReservations struct {
+	ttl time.Duration
+
+	lock  *sync.Mutex
+	lrps  map[string]inflightLRP
+	tasks map[string]inflightTask
+}
+
+type inflightLRP struct {
+	cellID   string
+	lrp      rep.LRP
+	placedAt time.Time
+}
+
+type inflightTask struct {
+	cellID   string
+	task     rep.Task
+	placedAt time.Time
+}
+
+func newInflightReservations(ttl time.Duration) *inflightReservations {
+	return &inflightReservations{
+		ttl:   ttl,
+		lock:  &sync.Mutex{},
+		lrps:  map[string]inflightLRP{},
+		tasks: map[string]inflightTask{},
+	}
+}
+
+// add remembers the work a round placed, or may have placed.
+func (r *inflightReservations) add(results auctiontypes.AuctionResults, now time.Time) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, lrpAuctions := range [][]auctiontypes.LRPAuction{results.SuccessfulLRPs, results.UnknownLRPs} {
+		for i := range lrpAuctions {
+			r.lrps[lrpAuctions[i].Identifier()] = inflightLRP{
+				cellID:   lrpAuctions[i].Winner,
+				lrp:      lrpAuctions[i].LRP,
+				placedAt: now,
+			}
+		}
+	}
+
+	for _, taskAuctions := range [][]auctiontypes.TaskAuction{results.SuccessfulTasks, results.UnknownTasks} {
+		for i := range taskAuctions {
+			r.tasks[taskAuctions[i].Identifier()] = inflightTask{
+				cellID:   taskAuctions[i].Winner,
+				task:     taskAuctions[i].Task,
+				placedAt: now,
+			}
+		}
+	}
+}
+
+// apply counts the remembered work against the cells it was placed on, as if
+// it were reserved there.  Work a cell's state already shows is forgotten, as
+// is work older than the TTL.  Work on cells missing from the zones is kept
+// for a later round.
+func (r *inflightReservations) apply(logger lager.Logger, zones map[string]Zone, now time.Time) {
+	r.applyTo(logger, zones, now, true)
+}
+
+// preview counts the remembered work against the cells as apply does, but
+// forgets none of it, for dry runs.
+func (r *inflightReservations) preview(logger lager.Logger, zones map[string]Zone, now time.Time) {
+	r.applyTo(logger, zones, now, false)
+}
+
+func (r *inflightReservations) applyTo(logger lager.Logger, zones map[string]Zone, now time.Time, forget bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	cells := map[string]*Cell{}
+	for _, zone := range zones {
+		for _, cell := range zone {
+			cells[cell.Guid] = cell
+		}
+	}
+
+	applied, confirmed, expired := 0, 0, 0
+
+	for identifier, inflight := range r.lrps {
+		if now.Sub(inflight.placedAt) >= r.ttl {
+			if forget {
+				delete(r.lrps, identifier)
+			}
+			expired++
+			continue
+		}
+
+		cell, ok := cells[inflight.cellID]
+		if !ok {
+			continue
+		}
+
+		lrp := inflight.lrp
+		if cell.addPendingLRP(&lrp) {
+			applied++
+		} else {
+			if forget {
+				delete(r.lrps, identifier)
+			}
+			confirmed++
+		}
+	}
+
+	for identifier, inflight := range r.tasks {
+		if now.Sub(inflight.placedAt) >= r.ttl {
+			if forget {
+				delete(r.tasks, identifier)
+			}
+			expired++
+			continue
+		}
+
+		cell, ok := cells[inflight.cellID]
+		if !ok {
+			continue
+		}
+
+		task := inflight.task
+		if cell.addPendingTask(&task) {
+			applied++
+		} else {
+			if forget {
+				delete(r.tasks, identifier)
+			}
+			confirmed++
+		}
+	}
+
+	logger.Info("applied-inflight-reservations", lager.Data{
+		"applied":   applied,
+		"confirmed": confirmed,
+		"expired":   expired,
+	})
+}
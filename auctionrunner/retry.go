@@ -0,0 +1,80 @@
+package auctionrunner
+
+import (
+	"math/rand"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+)
+
+// RetryPolicy says how often, and how far apart, a failing call is retried.
+// The zero value tries once.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries, including the first.  A
+	// negative value keeps retrying until the call succeeds.
+	MaxAttempts int
+
+	// InitialDelay is the wait before the first retry.  Each later wait is
+	// Multiplier times the one before, up to MaxDelay if that is set.  A
+	// Multiplier below 1 keeps the delay constant.
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+
+	// Jitter moves each delay by up to this fraction of itself, in either
+	// direction, so that retries from many callers spread out.
+	Jitter float64
+}
+
+var (
+	defaultCellDiscoveryRetryPolicy = RetryPolicy{MaxAttempts: -1, InitialDelay: time.Second}
+	defaultStateFetchRetryPolicy    = RetryPolicy{MaxAttempts: 4}
+	defaultCommitRetryPolicy        = RetryPolicy{MaxAttempts: 1}
+)
+
+// CanRetry reports whether another try is allowed after the given number of
+// tries.
+func (p RetryPolicy) CanRetry(attempts int) bool {
+	return p.MaxAttempts < 0 || attempts < p.MaxAttempts
+}
+
+// Delay returns how long to wait before the given retry, counting from 1.
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	delay := float64(p.InitialDelay)
+	if p.Multiplier > 1 {
+		for i := 1; i < retry; i++ {
+			delay *= p.Multiplier
+			if p.MaxDelay > 0 && delay >= float64(p.MaxDelay) {
+				break
+			}
+		}
+	}
+
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	if delay < 0 {
+		return 0
+	}
+	return time.Duration(delay)
+}
+
+// Do calls fn until it returns nil or the policy runs out of tries, waiting
+// on clk between tries.  It returns fn's last error.
+func (p RetryPolicy) Do(clk clock.Clock, fn func() error) error {
+	for attempts := 1; ; attempts++ {
+		err := fn()
+		if err == nil || !p.CanRetry(attempts) {
+			return err
+		}
+
+		if delay := p.Delay(attempts); delay > 0 {
+			clk.Sleep(delay)
+		}
+	}
+}
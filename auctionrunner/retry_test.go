@@ -0,0 +1,96 @@
+package auctionrunner_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/auction/auctionrunner"
+	"code.cloudfoundry.org/clock/fakeclock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RetryPolicy", func() {
+	Describe("CanRetry", func() {
+		It("tries once by default", func() {
+			Expect(auctionrunner.RetryPolicy{}.CanRetry(1)).To(BeFalse())
+		})
+
+		It("allows up to MaxAttempts tries", func() {
+			policy := auctionrunner.RetryPolicy{MaxAttempts: 3}
+			Expect(policy.CanRetry(2)).To(BeTrue())
+			Expect(policy.CanRetry(3)).To(BeFalse())
+		})
+
+		It("keeps retrying when MaxAttempts is negative", func() {
+			Expect(auctionrunner.RetryPolicy{MaxAttempts: -1}.CanRetry(1000)).To(BeTrue())
+		})
+	})
+
+	Describe("Delay", func() {
+		It("keeps the delay constant without a multiplier", func() {
+			policy := auctionrunner.RetryPolicy{InitialDelay: time.Second}
+			Expect(policy.Delay(1)).To(Equal(time.Second))
+			Expect(policy.Delay(5)).To(Equal(time.Second))
+		})
+
+		It("backs off exponentially, up to the maximum delay", func() {
+			policy := auctionrunner.RetryPolicy{InitialDelay: time.Second, Multiplier: 2, MaxDelay: 5 * time.Second}
+			Expect(policy.Delay(1)).To(Equal(time.Second))
+			Expect(policy.Delay(2)).To(Equal(2 * time.Second))
+			Expect(policy.Delay(3)).To(Equal(4 * time.Second))
+			Expect(policy.Delay(4)).To(Equal(5 * time.Second))
+			Expect(policy.Delay(100)).To(Equal(5 * time.Second))
+		})
+
+		It("moves the delay by no more than the jitter", func() {
+			policy := auctionrunner.RetryPolicy{InitialDelay: time.Second, Jitter: 0.5}
+			for i := 0; i < 100; i++ {
+				Expect(policy.Delay(1)).To(BeNumerically("~", time.Second, 500*time.Millisecond))
+			}
+		})
+	})
+
+	Describe("Do", func() {
+		var (
+			clock *fakeclock.FakeClock
+			calls int
+		)
+
+		BeforeEach(func() {
+			clock = fakeclock.NewFakeClock(time.Now())
+			calls = 0
+		})
+
+		It("returns as soon as the call succeeds", func() {
+			policy := auctionrunner.RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second}
+			err := policy.Do(clock, func() error {
+				calls++
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(calls).To(Equal(1))
+		})
+
+		It("waits on the clock between tries and returns the last error", func() {
+			policy := auctionrunner.RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second, Multiplier: 2}
+
+			errs := make(chan error, 1)
+			go func() {
+				defer GinkgoRecover()
+				errs <- policy.Do(clock, func() error {
+					calls++
+					return errors.New("boom")
+				})
+			}()
+
+			clock.WaitForWatcherAndIncrement(time.Second)
+			Consistently(errs).ShouldNot(Receive())
+
+			clock.WaitForWatcherAndIncrement(2 * time.Second)
+			Eventually(errs).Should(Receive(MatchError("boom")))
+			Expect(calls).To(Equal(3))
+		})
+	})
+})
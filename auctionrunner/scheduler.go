@@ -7,6 +7,7 @@ import (
 	"code.cloudfoundry.org/auction/auctiontypes"
 	"code.cloudfoundry.org/rep"
 
+	"code.cloudfoundry.org/bbs/trace"
 	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/workpool"
@@ -14,32 +15,6 @@ import (
 
 type Zone []*Cell
 
-func (z *Zone) filterCells(pc rep.PlacementConstraint) ([]*Cell, error) {
-	var cells = make([]*Cell, 0, len(*z))
-	err := auctiontypes.ErrorCellMismatch
-
-	for _, cell := range *z {
-		if cell.MatchRootFS(pc.RootFs) {
-			if err == auctiontypes.ErrorCellMismatch {
-				err = auctiontypes.ErrorVolumeDriverMismatch
-			}
-
-			if cell.MatchVolumeDrivers(pc.VolumeDrivers) {
-				if err == auctiontypes.ErrorVolumeDriverMismatch {
-					err = auctiontypes.NewPlacementTagMismatchError(pc.PlacementTags)
-				}
-
-				if cell.MatchPlacementTags(pc.PlacementTags) {
-					err = nil
-					cells = append(cells, cell)
-				}
-			}
-		}
-	}
-
-	return cells, err
-}
-
 func (z Zone) Len() int      { return len(z) }
 func (z Zone) Swap(i, j int) { z[i], z[j] = z[j], z[i] }
 func (z Zone) Less(i, j int) bool {
@@ -54,6 +29,100 @@ type Scheduler struct {
 	binPackFirstFitWeight         float64
 	startingContainerWeight       float64
 	startingContainerCountMaximum int // <=0 means no limit
+	scorer                        Scorer
+	filters                       placementFilters
+	explainPlacements             bool
+	commitRetryPolicy             RetryPolicy
+	secondChancePlacement         bool
+	reconcileCommits              bool
+	agingPolicy                   *AgingPolicy
+	taskGrouping                  TaskGrouping
+	failureDomains                FailureDomainProvider
+}
+
+type SchedulerOption func(*Scheduler)
+
+// WithPlacementExplanations makes the scheduler attach a PlacementExplanation
+// to every auction it places or fails to place.
+func WithPlacementExplanations() SchedulerOption {
+	return func(s *Scheduler) {
+		s.explainPlacements = true
+	}
+}
+
+// WithPlacementFilters adds filters to run after the default placement
+// filters. A rejection from a later filter is considered more specific than
+// one from an earlier filter.
+func WithPlacementFilters(filters ...PlacementFilter) SchedulerOption {
+	return func(s *Scheduler) {
+		s.filters = append(s.filters, filters...)
+	}
+}
+
+// WithCommitRetryPolicy retries sending work to a cell when the request
+// fails.  By default it is not retried: a failed request may have been partly
+// carried out, and a retry can then report work as failed that the cell in
+// fact started.
+func WithCommitRetryPolicy(retryPolicy RetryPolicy) SchedulerOption {
+	return func(s *Scheduler) {
+		s.commitRetryPolicy = retryPolicy
+	}
+}
+
+// WithCommitReconciliation makes the scheduler read a cell's state again
+// when sending it work fails, to find out which of the work it took on.
+// Work the cell does not have is reported as failed, and work whose fate
+// cannot be found out is reported in the results' UnknownLRPs and
+// UnknownTasks.  Without it, such work is reported as successful.
+func WithCommitReconciliation() SchedulerOption {
+	return func(s *Scheduler) {
+		s.reconcileCommits = true
+	}
+}
+
+// WithSecondChancePlacement makes Schedule place work that a cell refuses
+// when it is committed again, on the other cells, before reporting it as
+// failed.
+func WithSecondChancePlacement() SchedulerOption {
+	return func(s *Scheduler) {
+		s.secondChancePlacement = true
+	}
+}
+
+// WithAgingPolicy boosts the priority of work by how often it has been tried
+// and how long it has waited, before ordering it.  The boost applied is
+// reported in each auction's AgingBoost.  A runner given this option also
+// ages the work it chooses between when it caps the auctions in a round.
+func WithAgingPolicy(policy AgingPolicy) SchedulerOption {
+	return func(s *Scheduler) {
+		s.agingPolicy = &policy
+	}
+}
+
+// WithTaskZoneSpreading spreads the tasks of each group across zones,
+// placing a task in the zones with the fewest tasks of its group first, as
+// LRP instances are.  Without it, a task goes to the best scoring cell in any
+// zone.
+func WithTaskZoneSpreading(grouping TaskGrouping) SchedulerOption {
+	return func(s *Scheduler) {
+		s.taskGrouping = grouping
+	}
+}
+
+// WithFailureDomains spreads LRP instances across the failure domains the
+// provider places cells in, level by level, rather than across zones alone.
+// A spread constraint still limits the skew between zones.
+func WithFailureDomains(provider FailureDomainProvider) SchedulerOption {
+	return func(s *Scheduler) {
+		s.failureDomains = provider
+	}
+}
+
+// WithScorer replaces the DefaultScorer used to rank cells.
+func WithScorer(scorer Scorer) SchedulerOption {
+	return func(s *Scheduler) {
+		s.scorer = scorer
+	}
 }
 
 func NewScheduler(
@@ -64,8 +133,9 @@ func NewScheduler(
 	binPackFirstFitWeight float64,
 	startingContainerWeight float64,
 	startingContainerCountMaximum int,
+	opts ...SchedulerOption,
 ) *Scheduler {
-	return &Scheduler{
+	s := &Scheduler{
 		workPool:                      workPool,
 		zones:                         zones,
 		clock:                         clock,
@@ -73,7 +143,16 @@ func NewScheduler(
 		binPackFirstFitWeight:         binPackFirstFitWeight,
 		startingContainerWeight:       startingContainerWeight,
 		startingContainerCountMaximum: startingContainerCountMaximum,
+		scorer:                        DefaultScorer{},
+		filters:                       DefaultPlacementFilters(),
+		commitRetryPolicy:             defaultCommitRetryPolicy,
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 /*
@@ -85,24 +164,135 @@ work in batches at the end, for better network performance.  Schedule returns
 AuctionResults, indicating the success or failure of each requested job.
 */
 func (s *Scheduler) Schedule(auctionRequest auctiontypes.AuctionRequest) auctiontypes.AuctionResults {
-	results := auctiontypes.AuctionResults{}
-
 	if len(s.zones) == 0 {
-		results.FailedLRPs = auctionRequest.LRPs
-		for i := range results.FailedLRPs {
-			results.FailedLRPs[i].PlacementError = auctiontypes.ErrorCellCommunication.Error()
+		return s.markResults(failWithCellCommunicationError(auctionRequest))
+	}
+
+	placements := s.place(auctionRequest)
+
+	failedWorks, unknownWorks := s.commitCells()
+	if s.secondChancePlacement && len(failedWorks) > 0 {
+		var moreUnknownWorks []rep.Work
+		failedWorks, moreUnknownWorks = s.placeRejectedWork(placements, failedWorks)
+		unknownWorks = append(unknownWorks, moreUnknownWorks...)
+	}
+
+	results := placements.results
+	for _, failedWork := range failedWorks {
+		for _, failedStart := range failedWork.LRPs {
+			identifier := failedStart.Identifier()
+			delete(placements.successfulLRPs, identifier)
+
+			failedAuction := placements.lrpStartAuctionLookup[identifier]
+			s.loggerFor(failedAuction.AuctionRecord).Info("lrp-failed-to-be-placed", lager.Data{"lrp-guid": identifier})
+			results.FailedLRPs = append(results.FailedLRPs, *failedAuction)
 		}
-		results.FailedTasks = auctionRequest.Tasks
-		for i := range results.FailedTasks {
-			results.FailedTasks[i].PlacementError = auctiontypes.ErrorCellCommunication.Error()
+
+		for _, failedTask := range failedWork.Tasks {
+			identifier := failedTask.Identifier()
+			delete(placements.successfulTasks, identifier)
+
+			failedAuction := placements.taskAuctionLookup[identifier]
+			s.loggerFor(failedAuction.AuctionRecord).Info("task-failed-to-be-placed", lager.Data{"task-guid": identifier})
+			results.FailedTasks = append(results.FailedTasks, *failedAuction)
 		}
-		return s.markResults(results)
 	}
 
-	var successfulLRPs = map[string]*auctiontypes.LRPAuction{}
-	var lrpStartAuctionLookup = map[string]*auctiontypes.LRPAuction{}
-	var successfulTasks = map[string]*auctiontypes.TaskAuction{}
-	var taskAuctionLookup = map[string]*auctiontypes.TaskAuction{}
+	for _, unknownWork := range unknownWorks {
+		for _, unknownStart := range unknownWork.LRPs {
+			identifier := unknownStart.Identifier()
+			unknownAuction, ok := placements.successfulLRPs[identifier]
+			if !ok {
+				continue
+			}
+			delete(placements.successfulLRPs, identifier)
+
+			s.loggerFor(unknownAuction.AuctionRecord).Info("lrp-placement-unknown", lager.Data{"lrp-guid": identifier, "cell-guid": unknownAuction.Winner})
+			results.UnknownLRPs = append(results.UnknownLRPs, *unknownAuction)
+		}
+
+		for _, unknownTask := range unknownWork.Tasks {
+			identifier := unknownTask.Identifier()
+			unknownAuction, ok := placements.successfulTasks[identifier]
+			if !ok {
+				continue
+			}
+			delete(placements.successfulTasks, identifier)
+
+			s.loggerFor(unknownAuction.AuctionRecord).Info("task-placement-unknown", lager.Data{"task-guid": identifier, "cell-guid": unknownAuction.Winner})
+			results.UnknownTasks = append(results.UnknownTasks, *unknownAuction)
+		}
+	}
+
+	for _, successfulStart := range placements.successfulLRPs {
+		s.loggerFor(successfulStart.AuctionRecord).Info("lrp-added-to-cell", lager.Data{"lrp-guid": successfulStart.Identifier(), "cell-guid": successfulStart.Winner})
+		results.SuccessfulLRPs = append(results.SuccessfulLRPs, *successfulStart)
+	}
+	for _, successfulTask := range placements.successfulTasks {
+		s.loggerFor(successfulTask.AuctionRecord).Info("task-added-to-cell", lager.Data{"task-guid": successfulTask.Identifier(), "cell-guid": successfulTask.Winner})
+		results.SuccessfulTasks = append(results.SuccessfulTasks, *successfulTask)
+	}
+	return s.markResults(results)
+}
+
+/*
+Plan runs the same placement algorithm as Schedule but never commits the work
+to the cells.  The successful results carry the cell each job would have been
+placed on, and the failed results carry the reason it could not be placed.
+Attempts and WaitDuration are left untouched, since nothing was auctioned.
+
+Planning reserves resources on the scheduler's cells, so a Scheduler that has
+been used to Plan must not be used to Schedule.
+*/
+func (s *Scheduler) Plan(auctionRequest auctiontypes.AuctionRequest) auctiontypes.AuctionResults {
+	if len(s.zones) == 0 {
+		return failWithCellCommunicationError(auctionRequest)
+	}
+
+	placements := s.place(auctionRequest)
+	results := placements.results
+
+	for _, successfulStart := range placements.successfulLRPs {
+		results.SuccessfulLRPs = append(results.SuccessfulLRPs, *successfulStart)
+	}
+	for _, successfulTask := range placements.successfulTasks {
+		results.SuccessfulTasks = append(results.SuccessfulTasks, *successfulTask)
+	}
+	return results
+}
+
+type placements struct {
+	results               auctiontypes.AuctionResults
+	successfulLRPs        map[string]*auctiontypes.LRPAuction
+	lrpStartAuctionLookup map[string]*auctiontypes.LRPAuction
+	successfulTasks       map[string]*auctiontypes.TaskAuction
+	taskAuctionLookup     map[string]*auctiontypes.TaskAuction
+}
+
+func failWithCellCommunicationError(auctionRequest auctiontypes.AuctionRequest) auctiontypes.AuctionResults {
+	results := auctiontypes.AuctionResults{}
+	results.FailedLRPs = auctionRequest.LRPs
+	for i := range results.FailedLRPs {
+		results.FailedLRPs[i].PlacementError = auctiontypes.ErrorCellCommunication.Error()
+	}
+	results.FailedTasks = auctionRequest.Tasks
+	for i := range results.FailedTasks {
+		results.FailedTasks[i].PlacementError = auctiontypes.ErrorCellCommunication.Error()
+	}
+	return results
+}
+
+// place picks a cell for every job and reserves it there, without talking to
+// any cell.  Failures are recorded in the returned results; successes are
+// kept aside until the caller knows whether they were committed.
+func (s *Scheduler) place(auctionRequest auctiontypes.AuctionRequest) *placements {
+	p := &placements{
+		successfulLRPs:        map[string]*auctiontypes.LRPAuction{},
+		lrpStartAuctionLookup: map[string]*auctiontypes.LRPAuction{},
+		successfulTasks:       map[string]*auctiontypes.TaskAuction{},
+		taskAuctionLookup:     map[string]*auctiontypes.TaskAuction{},
+	}
+	results := &p.results
 	var currentInflightContainerStarts int
 
 	for _, zone := range s.zones {
@@ -111,18 +301,26 @@ func (s *Scheduler) Schedule(auctionRequest auctiontypes.AuctionRequest) auction
 		}
 	}
 
+	if s.agingPolicy != nil {
+		now := s.clock.Now()
+		for i := range auctionRequest.LRPs {
+			auctionRequest.LRPs[i].AgingBoost = s.agingPolicy.Boost(auctionRequest.LRPs[i].AuctionRecord, now)
+		}
+		for i := range auctionRequest.Tasks {
+			auctionRequest.Tasks[i].AgingBoost = s.agingPolicy.Boost(auctionRequest.Tasks[i].AuctionRecord, now)
+		}
+	}
+
 	sort.Sort(SortableLRPAuctions(auctionRequest.LRPs))
 	sort.Sort(SortableTaskAuctions(auctionRequest.Tasks))
 
-	lrpsBeforeTasks, lrpsAfterTasks := splitLRPS(auctionRequest.LRPs)
-
 	auctionLRP := func(lrpsToAuction []auctiontypes.LRPAuction) {
 		for i := range lrpsToAuction {
 			lrpAuction := &lrpsToAuction[i]
-			lrpStartAuctionLookup[lrpAuction.Identifier()] = lrpAuction
+			p.lrpStartAuctionLookup[lrpAuction.Identifier()] = lrpAuction
 
 			if s.exceededInflightContainerCreation(currentInflightContainerStarts) {
-				s.logger.Info(
+				s.loggerFor(lrpAuction.AuctionRecord).Info(
 					"exceeded-max-inflight-container-creation",
 					lager.Data{
 						"max-inflight": s.startingContainerCountMaximum,
@@ -139,71 +337,71 @@ func (s *Scheduler) Schedule(auctionRequest auctiontypes.AuctionRequest) auction
 				lrpAuction.PlacementError = err.Error()
 				results.FailedLRPs = append(results.FailedLRPs, *lrpAuction)
 			} else {
-				successfulLRPs[successfulStart.Identifier()] = successfulStart
+				p.successfulLRPs[successfulStart.Identifier()] = successfulStart
 				currentInflightContainerStarts++
 			}
 		}
 	}
 
-	auctionLRP(lrpsBeforeTasks)
+	auctionTasks := func(tasksToAuction []auctiontypes.TaskAuction) {
+		for i := range tasksToAuction {
+			taskAuction := &tasksToAuction[i]
+			p.taskAuctionLookup[taskAuction.Identifier()] = taskAuction
 
-	for i := range auctionRequest.Tasks {
-		taskAuction := &auctionRequest.Tasks[i]
-		taskAuctionLookup[taskAuction.Identifier()] = taskAuction
+			if s.exceededInflightContainerCreation(currentInflightContainerStarts) {
+				s.loggerFor(taskAuction.AuctionRecord).Info(
+					"exceeded-max-inflight-container-creation",
+					lager.Data{
+						"max-inflight": s.startingContainerCountMaximum,
+						"task-guid":    taskAuction.Identifier(),
+					},
+				)
+				taskAuction.PlacementError = auctiontypes.ErrorExceededInflightCreation.Error()
+				results.FailedTasks = append(results.FailedTasks, *taskAuction)
+				continue
+			}
 
-		if s.exceededInflightContainerCreation(currentInflightContainerStarts) {
-			s.logger.Info(
-				"exceeded-max-inflight-container-creation",
-				lager.Data{
-					"max-inflight": s.startingContainerCountMaximum,
-					"task-guid":    taskAuction.Identifier(),
-				},
-			)
-			taskAuction.PlacementError = auctiontypes.ErrorExceededInflightCreation.Error()
-			results.FailedTasks = append(results.FailedTasks, *taskAuction)
-			continue
+			successfulTask, err := s.scheduleTaskAuction(taskAuction, s.startingContainerWeight)
+			if err != nil {
+				taskAuction.PlacementError = err.Error()
+				results.FailedTasks = append(results.FailedTasks, *taskAuction)
+			} else {
+				p.successfulTasks[successfulTask.Identifier()] = successfulTask
+				currentInflightContainerStarts++
+			}
 		}
+	}
 
-		successfulTask, err := s.scheduleTaskAuction(taskAuction, s.startingContainerWeight)
-		if err != nil {
-			taskAuction.PlacementError = err.Error()
-			results.FailedTasks = append(results.FailedTasks, *taskAuction)
+	// work is placed a priority at a time, highest first.  Within a
+	// priority, first instances go ahead of tasks, and tasks ahead of later
+	// instances
+	lrps, tasks := auctionRequest.LRPs, auctionRequest.Tasks
+	for len(lrps) > 0 || len(tasks) > 0 {
+		var priority int
+		if len(lrps) > 0 && (len(tasks) == 0 || lrps[0].EffectivePriority() >= tasks[0].EffectivePriority()) {
+			priority = lrps[0].EffectivePriority()
 		} else {
-			successfulTasks[successfulTask.Identifier()] = successfulTask
-			currentInflightContainerStarts++
+			priority = tasks[0].EffectivePriority()
 		}
-	}
 
-	auctionLRP(lrpsAfterTasks)
-
-	failedWorks := s.commitCells()
-	for _, failedWork := range failedWorks {
-		for _, failedStart := range failedWork.LRPs {
-			identifier := failedStart.Identifier()
-			delete(successfulLRPs, identifier)
-
-			s.logger.Info("lrp-failed-to-be-placed", lager.Data{"lrp-guid": failedStart.Identifier()})
-			results.FailedLRPs = append(results.FailedLRPs, *lrpStartAuctionLookup[identifier])
+		lrpCount := 0
+		for lrpCount < len(lrps) && lrps[lrpCount].EffectivePriority() == priority {
+			lrpCount++
+		}
+		taskCount := 0
+		for taskCount < len(tasks) && tasks[taskCount].EffectivePriority() == priority {
+			taskCount++
 		}
 
-		for _, failedTask := range failedWork.Tasks {
-			identifier := failedTask.Identifier()
-			delete(successfulTasks, identifier)
+		lrpsBeforeTasks, lrpsAfterTasks := splitLRPS(lrps[:lrpCount])
+		auctionLRP(lrpsBeforeTasks)
+		auctionTasks(tasks[:taskCount])
+		auctionLRP(lrpsAfterTasks)
 
-			s.logger.Info("task-failed-to-be-placed", lager.Data{"task-guid": failedTask.Identifier()})
-			results.FailedTasks = append(results.FailedTasks, *taskAuctionLookup[identifier])
-		}
+		lrps, tasks = lrps[lrpCount:], tasks[taskCount:]
 	}
 
-	for _, successfulStart := range successfulLRPs {
-		s.logger.Info("lrp-added-to-cell", lager.Data{"lrp-guid": successfulStart.Identifier(), "cell-guid": successfulStart.Winner})
-		results.SuccessfulLRPs = append(results.SuccessfulLRPs, *successfulStart)
-	}
-	for _, successfulTask := range successfulTasks {
-		s.logger.Info("task-added-to-cell", lager.Data{"task-guid": successfulTask.Identifier(), "cell-guid": successfulTask.Winner})
-		results.SuccessfulTasks = append(results.SuccessfulTasks, *successfulTask)
-	}
-	return s.markResults(results)
+	return p
 }
 
 func (s *Scheduler) markResults(results auctiontypes.AuctionResults) auctiontypes.AuctionResults {
@@ -223,47 +421,129 @@ func (s *Scheduler) markResults(results auctiontypes.AuctionResults) auctiontype
 		results.SuccessfulTasks[i].Attempts++
 		results.SuccessfulTasks[i].WaitDuration = now.Sub(results.SuccessfulTasks[i].QueueTime)
 	}
+	for i := range results.UnknownLRPs {
+		results.UnknownLRPs[i].Attempts++
+		results.UnknownLRPs[i].WaitDuration = now.Sub(results.UnknownLRPs[i].QueueTime)
+	}
+	for i := range results.UnknownTasks {
+		results.UnknownTasks[i].Attempts++
+		results.UnknownTasks[i].WaitDuration = now.Sub(results.UnknownTasks[i].QueueTime)
+	}
 
 	return results
 }
 
+// splitLRPS separates the first instances of processes, which are placed
+// before tasks, from the later ones.  Each part keeps the order the auctions
+// were sorted in.
 func splitLRPS(lrps []auctiontypes.LRPAuction) ([]auctiontypes.LRPAuction, []auctiontypes.LRPAuction) {
 	const pivot = 0
 
-	for idx, lrp := range lrps {
-		if lrp.Index > pivot {
-			return lrps[:idx], lrps[idx:]
+	before := make([]auctiontypes.LRPAuction, 0, len(lrps))
+	after := make([]auctiontypes.LRPAuction, 0, len(lrps))
+	for i := range lrps {
+		if lrps[i].Index > pivot {
+			after = append(after, lrps[i])
+		} else {
+			before = append(before, lrps[i])
 		}
 	}
 
-	return lrps[:0], lrps[0:]
+	return before, after
 }
 
-func (s *Scheduler) commitCells() []rep.Work {
+// commitCells sends every cell its reserved work.  It returns the work each
+// cell refused, and the work that may or may not have been placed.
+func (s *Scheduler) commitCells() (map[*Cell]rep.Work, []rep.Work) {
 	wg := &sync.WaitGroup{}
 	for _, cells := range s.zones {
 		wg.Add(len(cells))
 	}
 
 	lock := &sync.Mutex{}
-	failedWorks := []rep.Work{}
+	failedWorks := map[*Cell]rep.Work{}
+	unknownWorks := []rep.Work{}
 
 	for _, cells := range s.zones {
 		for _, cell := range cells {
 			cell := cell
 			s.workPool.Submit(func() {
 				defer wg.Done()
-				failedWork := cell.Commit()
+				result := cell.commit(s.clock, s.commitRetryPolicy, s.reconcileCommits)
 
 				lock.Lock()
-				failedWorks = append(failedWorks, failedWork)
-				lock.Unlock()
+				defer lock.Unlock()
+				if len(result.failed.LRPs) > 0 || len(result.failed.Tasks) > 0 {
+					failedWorks[cell] = result.failed
+				}
+				if len(result.unknown.LRPs) > 0 || len(result.unknown.Tasks) > 0 {
+					unknownWorks = append(unknownWorks, result.unknown)
+				}
 			})
 		}
 	}
 
 	wg.Wait()
-	return failedWorks
+	return failedWorks, unknownWorks
+}
+
+// placeRejectedWork gives work refused by a cell at commit time a second
+// chance: the cell's reservation is cancelled, the work is placed again on
+// the best of the other cells and committed there.  Work that cannot be
+// placed again is added to the failed results; what became of the rest is
+// returned as from commitCells.
+func (s *Scheduler) placeRejectedWork(p *placements, failedWorks map[*Cell]rep.Work) (map[*Cell]rep.Work, []rep.Work) {
+	rejected := rejectedAtCommitFilter{lrps: map[string]string{}, tasks: map[string]string{}}
+	lrpAuctions := []*auctiontypes.LRPAuction{}
+	taskAuctions := []*auctiontypes.TaskAuction{}
+
+	for cell, failedWork := range failedWorks {
+		for i := range failedWork.LRPs {
+			lrp := &failedWork.LRPs[i]
+			identifier := lrp.Identifier()
+			cell.cancelLRPReservation(lrp)
+			rejected.lrps[identifier] = cell.Guid
+			delete(p.successfulLRPs, identifier)
+			lrpAuctions = append(lrpAuctions, p.lrpStartAuctionLookup[identifier])
+		}
+
+		for i := range failedWork.Tasks {
+			task := &failedWork.Tasks[i]
+			identifier := task.Identifier()
+			cell.cancelTaskReservation(task)
+			rejected.tasks[identifier] = cell.Guid
+			delete(p.successfulTasks, identifier)
+			taskAuctions = append(taskAuctions, p.taskAuctionLookup[identifier])
+		}
+	}
+
+	filters := s.filters
+	s.filters = append(filters[:len(filters):len(filters)], rejected)
+	defer func() { s.filters = filters }()
+
+	for _, lrpAuction := range lrpAuctions {
+		s.loggerFor(lrpAuction.AuctionRecord).Info("lrp-rejected-by-cell-placing-again", lager.Data{"lrp-guid": lrpAuction.Identifier(), "cell-guid": rejected.lrps[lrpAuction.Identifier()]})
+		successfulStart, err := s.scheduleLRPAuction(lrpAuction)
+		if err != nil {
+			lrpAuction.PlacementError = err.Error()
+			p.results.FailedLRPs = append(p.results.FailedLRPs, *lrpAuction)
+			continue
+		}
+		p.successfulLRPs[successfulStart.Identifier()] = successfulStart
+	}
+
+	for _, taskAuction := range taskAuctions {
+		s.loggerFor(taskAuction.AuctionRecord).Info("task-rejected-by-cell-placing-again", lager.Data{"task-guid": taskAuction.Identifier(), "cell-guid": rejected.tasks[taskAuction.Identifier()]})
+		successfulTask, err := s.scheduleTaskAuction(taskAuction, s.startingContainerWeight)
+		if err != nil {
+			taskAuction.PlacementError = err.Error()
+			p.results.FailedTasks = append(p.results.FailedTasks, *taskAuction)
+			continue
+		}
+		p.successfulTasks[successfulTask.Identifier()] = successfulTask
+	}
+
+	return s.commitCells()
 }
 
 type CellResourceState struct {
@@ -289,12 +569,30 @@ func NewCellResourceState(state rep.CellState) CellResourceState {
 func (s *Scheduler) scheduleLRPAuction(lrpAuction *auctiontypes.LRPAuction) (*auctiontypes.LRPAuction, error) {
 	var winnerCell *Cell
 	winnerScore := 1e20
+	explanation := s.newExplanation()
 
 	zones := accumulateZonesByInstances(s.zones, lrpAuction.ProcessGuid)
 
-	filteredZones, err := filterZones(zones, lrpAuction)
-	if err != nil {
-		return nil, err
+	filteredZones, rejections := filterZones(zones, s.lrpFilters(lrpAuction), &lrpAuction.LRP)
+	explanation.rejectedByFilters(rejections)
+	if len(filteredZones) == 0 {
+		lrpAuction.Explanation = explanation.placementExplanation(nil)
+		return nil, mostSpecificRejection(rejections)
+	}
+
+	var excludedZones []lrpByZone
+	spread := lrpAuction.Constraints.Spread
+	if spread != nil {
+		filteredZones, excludedZones = applySpreadConstraint(*spread, filteredZones)
+		for _, excludedZone := range excludedZones {
+			explanation.notConsidered(excludedZone.zone, reasonZoneExceedsMaxSkew)
+		}
+	}
+
+	skippedReason := reasonZoneHasMoreInstances
+	if s.failureDomains != nil {
+		filteredZones = splitByFailureDomain(filteredZones, s.zones, s.failureDomains, lrpAuction.ProcessGuid)
+		skippedReason = reasonDomainHasMoreInstances
 	}
 
 	sortedZones := sortZonesByInstances(filteredZones)
@@ -304,15 +602,18 @@ func (s *Scheduler) scheduleLRPAuction(lrpAuction *auctiontypes.LRPAuction) (*au
 
 	for zoneIndex, lrpByZone := range sortedZones {
 		for _, cell := range lrpByZone.zone {
-			score, err := cell.ScoreForLRP(&lrpAuction.LRP, s.startingContainerWeight, s.binPackFirstFitWeight)
+			score, err := s.scoreLRP(cell, &lrpAuction.LRP)
 			if err != nil {
 				cellStates[cell.Guid] = NewCellResourceState(cell.State())
 				removeNonApplicableProblems(problems, err)
+				explanation.rejectedByScorer(cell, err)
 				continue
 			}
+			score = applyAffinity(score, cell, lrpAuction.Constraints.Affinity)
+			explanation.scored(cell, score)
 
-			if score < winnerScore {
-				winnerScore = score
+			if score.Total < winnerScore {
+				winnerScore = score.Total
 				winnerCell = cell
 			}
 		}
@@ -320,96 +621,163 @@ func (s *Scheduler) scheduleLRPAuction(lrpAuction *auctiontypes.LRPAuction) (*au
 		// if (not last zone) && (this zone has the same # of instances as the next sorted zone)
 		// acts as a tie breaker
 		if zoneIndex+1 < len(sortedZones) &&
-			lrpByZone.instances == sortedZones[zoneIndex+1].instances {
+			lrpByZone.sameSpread(sortedZones[zoneIndex+1]) {
 			continue
 		}
 
 		if winnerCell != nil {
+			for _, skippedZone := range sortedZones[zoneIndex+1:] {
+				explanation.notConsidered(skippedZone.zone, skippedReason)
+			}
 			break
 		}
 	}
 
 	if winnerCell == nil {
-		err := &rep.InsufficientResourcesError{Problems: problems}
-		s.logger.Error("lrp-auction-failed", err, lager.Data{"lrp-guid": lrpAuction.Identifier(), "lrp-instance-guid": lrpAuction.LRP.InstanceGUID, "lrp-placement-constraints": lrpAuction.LRP.PlacementConstraint, "lrp-resource": lrpAuction.LRP.Resource})
+		var err error = &rep.InsufficientResourcesError{Problems: problems}
+		if capErr := s.capBlockedPlacement(rejections, &lrpAuction.LRP); capErr != nil {
+			err = capErr
+		} else if s.anyCellFits(excludedZones, &lrpAuction.LRP) {
+			err = auctiontypes.SpreadConstraintError{
+				Constraint: *spread,
+				Reason:     "only zones beyond the maximum skew have room",
+			}
+		}
+		s.loggerFor(lrpAuction.AuctionRecord).Error("lrp-auction-failed", err, lager.Data{"lrp-guid": lrpAuction.Identifier(), "lrp-instance-guid": lrpAuction.LRP.InstanceGUID, "lrp-placement-constraints": lrpAuction.LRP.PlacementConstraint, "lrp-resource": lrpAuction.LRP.Resource})
 		s.logger.Debug("cells-failing-score-for-lrp", lager.Data{"states": cellStates})
+		lrpAuction.Explanation = explanation.placementExplanation(nil)
 		return nil, err
 	}
 
-	err = winnerCell.ReserveLRP(&lrpAuction.LRP)
+	err := winnerCell.ReserveLRP(&lrpAuction.LRP)
 	if err != nil {
-		s.logger.Error("lrp-failed-to-reserve-cell", err, lager.Data{"cell-guid": winnerCell.Guid, "lrp-guid": lrpAuction.Identifier(), "lrp-instance-guid": lrpAuction.LRP.InstanceGUID, "lrp-placement-constraints": lrpAuction.LRP.PlacementConstraint, "lrp-resource": lrpAuction.LRP.Resource})
+		s.loggerFor(lrpAuction.AuctionRecord).Error("lrp-failed-to-reserve-cell", err, lager.Data{"cell-guid": winnerCell.Guid, "lrp-guid": lrpAuction.Identifier(), "lrp-instance-guid": lrpAuction.LRP.InstanceGUID, "lrp-placement-constraints": lrpAuction.LRP.PlacementConstraint, "lrp-resource": lrpAuction.LRP.Resource})
 		s.logger.Debug("cells-failing-score-for-lrp", lager.Data{"states": cellStates})
+		lrpAuction.Explanation = explanation.placementExplanation(nil)
 		return nil, err
 	}
 
+	lrpAuction.Explanation = explanation.placementExplanation(winnerCell)
 	winningAuction := lrpAuction.Copy()
 	winningAuction.Winner = winnerCell.Guid
 	return &winningAuction, nil
 }
 
-func (s *Scheduler) scheduleTaskAuction(taskAuction *auctiontypes.TaskAuction, startingContainerWeight float64) (*auctiontypes.TaskAuction, error) {
-	var winnerCell *Cell
-	winnerScore := 1e20
-
-	filteredZones := []Zone{}
-	var zoneError error
-
-	for _, zone := range s.zones {
-		cells, err := zone.filterCells(taskAuction.PlacementConstraint)
-		if err != nil {
-			_, isZoneErrorPlacementTagMismatchError := zoneError.(auctiontypes.PlacementTagMismatchError)
-			_, isErrPlacementTagMismatchError := err.(auctiontypes.PlacementTagMismatchError)
+// lrpFilters returns the filters to place the LRP with, which add its caps
+// and hard affinity rules to the scheduler's.
+func (s *Scheduler) lrpFilters(lrpAuction *auctiontypes.LRPAuction) placementFilters {
+	return s.filters.
+		withInstanceCaps(s.zones, lrpAuction.ProcessGuid, lrpAuction.Constraints).
+		withAffinity(lrpAuction.Constraints.Affinity)
+}
 
-			if isZoneErrorPlacementTagMismatchError ||
-				(zoneError == auctiontypes.ErrorVolumeDriverMismatch && isErrPlacementTagMismatchError) ||
-				zoneError == auctiontypes.ErrorCellMismatch || zoneError == nil {
-				zoneError = err
+// anyCellFits reports whether any cell in the given zones could take the LRP.
+func (s *Scheduler) anyCellFits(zones []lrpByZone, lrp *rep.LRP) bool {
+	for _, lrpZone := range zones {
+		for _, cell := range lrpZone.zone {
+			if _, err := s.scoreLRP(cell, lrp); err == nil {
+				return true
 			}
-			continue
 		}
+	}
+	return false
+}
+
+// loggerFor logs under the trace the auction was submitted with.
+func (s *Scheduler) loggerFor(record auctiontypes.AuctionRecord) lager.Logger {
+	return trace.LoggerWithTraceInfo(s.logger, record.TraceID)
+}
+
+func (s *Scheduler) scheduleTaskAuction(taskAuction *auctiontypes.TaskAuction, startingContainerWeight float64) (*auctiontypes.TaskAuction, error) {
+	var winnerCell *Cell
+	winnerScore := 1e20
+	explanation := s.newExplanation()
 
-		filteredZones = append(filteredZones, Zone(cells))
+	group := ""
+	if s.taskGrouping != nil {
+		group = s.taskGrouping.TaskGroup(&taskAuction.Task)
 	}
+	zones := accumulateZonesByTasks(s.zones, s.taskGrouping, group)
 
+	filteredZones, rejections := filterTaskZones(zones, s.filters.withAffinity(taskAuction.Constraints.Affinity), &taskAuction.Task)
+	explanation.rejectedByFilters(rejections)
 	if len(filteredZones) == 0 {
-		return nil, zoneError
+		taskAuction.Explanation = explanation.placementExplanation(nil)
+		return nil, mostSpecificRejection(rejections)
 	}
 
 	problems := map[string]struct{}{"disk": struct{}{}, "memory": struct{}{}, "containers": struct{}{}}
 
-	for _, zone := range filteredZones {
-		for _, cell := range zone {
-			score, err := cell.ScoreForTask(&taskAuction.Task, startingContainerWeight)
+	// without a group every zone has no tasks, so all cells are pooled
+	sortedZones := sortZonesByInstances(filteredZones)
+	for zoneIndex, taskZone := range sortedZones {
+		for _, cell := range taskZone.zone {
+			score, err := s.scoreTask(cell, &taskAuction.Task, startingContainerWeight)
 			if err != nil {
 				removeNonApplicableProblems(problems, err)
+				explanation.rejectedByScorer(cell, err)
 				continue
 			}
+			score = applyAffinity(score, cell, taskAuction.Constraints.Affinity)
+			explanation.scored(cell, score)
 
-			if score < winnerScore {
-				winnerScore = score
+			if score.Total < winnerScore {
+				winnerScore = score.Total
 				winnerCell = cell
 			}
 		}
+
+		if zoneIndex+1 < len(sortedZones) &&
+			taskZone.sameSpread(sortedZones[zoneIndex+1]) {
+			continue
+		}
+
+		if winnerCell != nil {
+			for _, skippedZone := range sortedZones[zoneIndex+1:] {
+				explanation.notConsidered(skippedZone.zone, reasonZoneHasMoreTasks)
+			}
+			break
+		}
 	}
 
 	if winnerCell == nil {
 		err := &rep.InsufficientResourcesError{Problems: problems}
-		s.logger.Error("task-auction-failed", err, lager.Data{"task-guid": taskAuction.Identifier()})
+		s.loggerFor(taskAuction.AuctionRecord).Error("task-auction-failed", err, lager.Data{"task-guid": taskAuction.Identifier()})
+		taskAuction.Explanation = explanation.placementExplanation(nil)
 		return nil, err
 	}
 
 	err := winnerCell.ReserveTask(&taskAuction.Task)
 	if err != nil {
-		s.logger.Error("task-failed-to-reserve-cell", err, lager.Data{"cell-guid": winnerCell.Guid, "task-guid": taskAuction.Identifier()})
+		s.loggerFor(taskAuction.AuctionRecord).Error("task-failed-to-reserve-cell", err, lager.Data{"cell-guid": winnerCell.Guid, "task-guid": taskAuction.Identifier()})
+		taskAuction.Explanation = explanation.placementExplanation(nil)
 		return nil, err
 	}
 
+	taskAuction.Explanation = explanation.placementExplanation(winnerCell)
 	winningAuction := taskAuction.Copy()
 	winningAuction.Winner = winnerCell.Guid
 	return &winningAuction, nil
 }
 
+func (s *Scheduler) scoreLRP(cell *Cell, lrp *rep.LRP) (auctiontypes.ScoreBreakdown, error) {
+	if scorer, ok := s.scorer.(BreakdownScorer); ok && s.explainPlacements {
+		return scorer.LRPScoreBreakdown(cell, lrp, s.startingContainerWeight, s.binPackFirstFitWeight)
+	}
+
+	score, err := s.scorer.ScoreForLRP(cell, lrp, s.startingContainerWeight, s.binPackFirstFitWeight)
+	return auctiontypes.ScoreBreakdown{Total: score}, err
+}
+
+func (s *Scheduler) scoreTask(cell *Cell, task *rep.Task, startingContainerWeight float64) (auctiontypes.ScoreBreakdown, error) {
+	if scorer, ok := s.scorer.(BreakdownScorer); ok && s.explainPlacements {
+		return scorer.TaskScoreBreakdown(cell, task, startingContainerWeight)
+	}
+
+	score, err := s.scorer.ScoreForTask(cell, task, startingContainerWeight)
+	return auctiontypes.ScoreBreakdown{Total: score}, err
+}
+
 // removeNonApplicableProblems modifies the 'problems' map to remove any problems that didn't show up on err.
 //
 // The list of problems to report should only consist of the problems that exist on every cell
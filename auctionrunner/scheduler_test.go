@@ -1,6 +1,7 @@
 package auctionrunner_test
 
 import (
+	"errors"
 	"time"
 
 	"code.cloudfoundry.org/clock/fakeclock"
@@ -1148,6 +1149,1440 @@ var _ = Describe("Scheduler", func() {
 			})
 		})
 	})
+
+	Describe("scoring cells", func() {
+		var (
+			startAuction auctiontypes.LRPAuction
+			taskAuction  auctiontypes.TaskAuction
+		)
+
+		BeforeEach(func() {
+			clients["A-cell"] = &repfakes.FakeSimClient{}
+			zones["A-zone"] = auctionrunner.Zone{
+				auctionrunner.NewCell(
+					logger,
+					"A-cell",
+					clients["A-cell"],
+					BuildCellState("cellID", 0, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{
+						*BuildLRP("pg-1", "domain", 0, "", 10, 10, 10, []string{}),
+						*BuildLRP("pg-2", "domain", 0, "", 10, 10, 10, []string{}),
+					}, []string{}, []string{}, []string{}, 0),
+				),
+			}
+
+			clients["B-cell"] = &repfakes.FakeSimClient{}
+			zones["A-zone"] = append(zones["A-zone"], auctionrunner.NewCell(
+				logger,
+				"B-cell",
+				clients["B-cell"],
+				BuildCellState("cellID", 1, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{}, []string{}, []string{}, []string{}, 0),
+			))
+
+			startAuction = BuildLRPAuction("pg-3", "domain", 0, linuxRootFSURL, 10, 10, 10, clock.Now(), nil, []string{})
+			taskAuction = BuildTaskAuction(BuildTask("tg-1", "domain", linuxRootFSURL, 10, 10, 10, []string{}, []string{}), clock.Now())
+		})
+
+		Context("with the default scorer", func() {
+			BeforeEach(func() {
+				s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0)
+				results = s.Schedule(auctiontypes.AuctionRequest{
+					LRPs:  []auctiontypes.LRPAuction{startAuction},
+					Tasks: []auctiontypes.TaskAuction{taskAuction},
+				})
+			})
+
+			It("places work on the least loaded cell", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].Winner).To(Equal("B-cell"))
+				Expect(results.SuccessfulTasks).To(HaveLen(1))
+				Expect(results.SuccessfulTasks[0].Winner).To(Equal("B-cell"))
+			})
+		})
+
+		Context("with a custom scorer", func() {
+			BeforeEach(func() {
+				s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0, auctionrunner.WithScorer(preferCellScorer{cellGuid: "A-cell"}))
+				results = s.Schedule(auctiontypes.AuctionRequest{
+					LRPs:  []auctiontypes.LRPAuction{startAuction},
+					Tasks: []auctiontypes.TaskAuction{taskAuction},
+				})
+			})
+
+			It("places work according to the custom scores", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].Winner).To(Equal("A-cell"))
+				Expect(results.SuccessfulTasks).To(HaveLen(1))
+				Expect(results.SuccessfulTasks[0].Winner).To(Equal("A-cell"))
+
+				Expect(clients["A-cell"].PerformCallCount()).To(Equal(1))
+				Expect(clients["B-cell"].PerformCallCount()).To(Equal(0))
+			})
+
+			Context("when the scorer rejects every cell", func() {
+				BeforeEach(func() {
+					s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0, auctionrunner.WithScorer(preferCellScorer{cellGuid: "A-cell", rejectAll: true}))
+					results = s.Schedule(auctiontypes.AuctionRequest{
+						LRPs:  []auctiontypes.LRPAuction{startAuction},
+						Tasks: []auctiontypes.TaskAuction{taskAuction},
+					})
+				})
+
+				It("fails the work", func() {
+					Expect(results.SuccessfulLRPs).To(BeEmpty())
+					Expect(results.FailedLRPs).To(HaveLen(1))
+					Expect(results.SuccessfulTasks).To(BeEmpty())
+					Expect(results.FailedTasks).To(HaveLen(1))
+				})
+			})
+		})
+	})
+
+	Describe("custom placement filters", func() {
+		var (
+			startAuction auctiontypes.LRPAuction
+			taskAuction  auctiontypes.TaskAuction
+			filter       *maintenanceFilter
+		)
+
+		BeforeEach(func() {
+			clients["A-cell"] = &repfakes.FakeSimClient{}
+			zones["A-zone"] = auctionrunner.Zone{
+				auctionrunner.NewCell(
+					logger,
+					"A-cell",
+					clients["A-cell"],
+					BuildCellState("cellID", 0, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{}, []string{}, []string{}, []string{}, 0),
+				),
+			}
+
+			clients["B-cell"] = &repfakes.FakeSimClient{}
+			zones["B-zone"] = auctionrunner.Zone{
+				auctionrunner.NewCell(
+					logger,
+					"B-cell",
+					clients["B-cell"],
+					BuildCellState("cellID", 0, "B-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{
+						*BuildLRP("pg-1", "domain", 0, "", 10, 10, 10, []string{}),
+					}, []string{}, []string{}, []string{}, 0),
+				),
+			}
+
+			startAuction = BuildLRPAuction("pg-2", "domain", 0, linuxRootFSURL, 10, 10, 10, clock.Now(), nil, []string{})
+			taskAuction = BuildTaskAuction(BuildTask("tg-1", "domain", linuxRootFSURL, 10, 10, 10, []string{}, []string{}), clock.Now())
+			filter = &maintenanceFilter{cells: map[string]bool{"A-cell": true}}
+		})
+
+		JustBeforeEach(func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0, auctionrunner.WithPlacementFilters(filter))
+			results = s.Schedule(auctiontypes.AuctionRequest{
+				LRPs:  []auctiontypes.LRPAuction{startAuction},
+				Tasks: []auctiontypes.TaskAuction{taskAuction},
+			})
+		})
+
+		It("does not place work on cells the filter rejects", func() {
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulLRPs[0].Winner).To(Equal("B-cell"))
+			Expect(results.SuccessfulTasks).To(HaveLen(1))
+			Expect(results.SuccessfulTasks[0].Winner).To(Equal("B-cell"))
+			Expect(clients["A-cell"].PerformCallCount()).To(Equal(0))
+		})
+
+		Context("when the filter rejects every cell", func() {
+			BeforeEach(func() {
+				filter.cells["B-cell"] = true
+			})
+
+			It("reports the filter's reason", func() {
+				Expect(results.FailedLRPs).To(HaveLen(1))
+				Expect(results.FailedLRPs[0].PlacementError).To(Equal(errCellInMaintenance.Error()))
+				Expect(results.FailedTasks).To(HaveLen(1))
+				Expect(results.FailedTasks[0].PlacementError).To(Equal(errCellInMaintenance.Error()))
+			})
+
+			Context("and a default filter rejects some cells first", func() {
+				BeforeEach(func() {
+					startAuction = BuildLRPAuction("pg-2", "domain", 0, linuxRootFSURL, 10, 10, 10, clock.Now(), []string{"driver-1"}, []string{})
+					zones["B-zone"][0] = auctionrunner.NewCell(
+						logger,
+						"B-cell",
+						clients["B-cell"],
+						BuildCellState("cellID", 0, "B-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{}, []string{"driver-1"}, []string{}, []string{}, 0),
+					)
+				})
+
+				It("reports the reason from the cell that got furthest through the filters", func() {
+					Expect(results.FailedLRPs).To(HaveLen(1))
+					Expect(results.FailedLRPs[0].PlacementError).To(Equal(errCellInMaintenance.Error()))
+				})
+			})
+		})
+
+		Context("when a default filter rejects a cell before the custom filter sees it", func() {
+			BeforeEach(func() {
+				startAuction = BuildLRPAuction("pg-2", "domain", 0, windowsRootFSURL, 10, 10, 10, clock.Now(), nil, []string{})
+			})
+
+			It("does not ask the custom filter about it", func() {
+				Expect(results.FailedLRPs).To(HaveLen(1))
+				Expect(results.FailedLRPs[0].PlacementError).To(Equal(auctiontypes.ErrorCellMismatch.Error()))
+				Expect(filter.lrpsSeen).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("retrying commits", func() {
+		var startAuction auctiontypes.LRPAuction
+
+		BeforeEach(func() {
+			clients["A-cell"] = &repfakes.FakeSimClient{}
+			zones["A-zone"] = auctionrunner.Zone{
+				auctionrunner.NewCell(
+					logger,
+					"A-cell",
+					clients["A-cell"],
+					BuildCellState("cellID", 0, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, nil, []string{}, []string{}, []string{}, 0),
+				),
+			}
+
+			startAuction = BuildLRPAuction("pg-1", "domain", 0, linuxRootFSURL, 10, 10, 10, clock.Now(), nil, []string{})
+
+			clients["A-cell"].PerformReturnsOnCall(0, rep.Work{}, errors.New("boom"))
+			clients["A-cell"].PerformReturnsOnCall(1, rep.Work{}, nil)
+		})
+
+		It("does not retry by default", func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0)
+			s.Schedule(auctiontypes.AuctionRequest{LRPs: []auctiontypes.LRPAuction{startAuction}})
+			Expect(clients["A-cell"].PerformCallCount()).To(Equal(1))
+		})
+
+		It("retries a failed commit as the retry policy says", func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0,
+				auctionrunner.WithCommitRetryPolicy(auctionrunner.RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second}),
+			)
+
+			done := make(chan auctiontypes.AuctionResults)
+			go func() {
+				done <- s.Schedule(auctiontypes.AuctionRequest{LRPs: []auctiontypes.LRPAuction{startAuction}})
+			}()
+
+			clock.WaitForWatcherAndIncrement(time.Second)
+
+			Eventually(done).Should(Receive(&results))
+			Expect(clients["A-cell"].PerformCallCount()).To(Equal(2))
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+		})
+	})
+
+	Describe("reconciling commits", func() {
+		var (
+			startAuction auctiontypes.LRPAuction
+			taskAuction  auctiontypes.TaskAuction
+			options      []auctionrunner.SchedulerOption
+		)
+
+		BeforeEach(func() {
+			clients["A-cell"] = &repfakes.FakeSimClient{}
+			zones["A-zone"] = auctionrunner.Zone{
+				auctionrunner.NewCell(
+					logger,
+					"A-cell",
+					clients["A-cell"],
+					BuildCellState("A-cell", 0, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, nil, []string{}, []string{}, []string{}, 0),
+				),
+			}
+
+			startAuction = BuildLRPAuction("pg-1", "domain", 0, linuxRootFSURL, 10, 10, 10, clock.Now(), nil, []string{})
+			taskAuction = BuildTaskAuction(BuildTask("tg-1", "domain", linuxRootFSURL, 10, 10, 10, []string{}, []string{}), clock.Now())
+
+			clients["A-cell"].PerformReturns(rep.Work{}, errors.New("connection reset"))
+			clients["A-cell"].StateReturns(BuildCellState("A-cell", 0, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{
+				startAuction.LRP,
+			}, []string{}, []string{}, []string{}, 0), nil)
+
+			options = nil
+		})
+
+		JustBeforeEach(func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0, options...)
+			results = s.Schedule(auctiontypes.AuctionRequest{
+				LRPs:  []auctiontypes.LRPAuction{startAuction},
+				Tasks: []auctiontypes.TaskAuction{taskAuction},
+			})
+		})
+
+		It("reports work sent with a failed commit as successful by default", func() {
+			Expect(clients["A-cell"].StateCallCount()).To(BeZero())
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulTasks).To(HaveLen(1))
+			Expect(results.UnknownLRPs).To(BeEmpty())
+			Expect(results.UnknownTasks).To(BeEmpty())
+		})
+
+		Context("when enabled", func() {
+			BeforeEach(func() {
+				options = append(options, auctionrunner.WithCommitReconciliation())
+			})
+
+			It("reports work the cell has as successful, and work it lacks as failed", func() {
+				Expect(clients["A-cell"].StateCallCount()).To(Equal(1))
+
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-1"))
+				Expect(results.SuccessfulTasks).To(BeEmpty())
+
+				Expect(results.FailedTasks).To(HaveLen(1))
+				Expect(results.FailedTasks[0].TaskGuid).To(Equal("tg-1"))
+				Expect(results.FailedTasks[0].Attempts).To(Equal(1))
+			})
+
+			Context("when the cell's state cannot be read", func() {
+				BeforeEach(func() {
+					clients["A-cell"].StateReturns(rep.CellState{}, errors.New("connection refused"))
+				})
+
+				It("reports the work as unknown", func() {
+					Expect(results.SuccessfulLRPs).To(BeEmpty())
+					Expect(results.SuccessfulTasks).To(BeEmpty())
+					Expect(results.FailedLRPs).To(BeEmpty())
+					Expect(results.FailedTasks).To(BeEmpty())
+
+					Expect(results.UnknownLRPs).To(HaveLen(1))
+					Expect(results.UnknownLRPs[0].ProcessGuid).To(Equal("pg-1"))
+					Expect(results.UnknownLRPs[0].Winner).To(Equal("A-cell"))
+					Expect(results.UnknownLRPs[0].Attempts).To(Equal(1))
+					Expect(results.UnknownTasks).To(HaveLen(1))
+					Expect(results.UnknownTasks[0].TaskGuid).To(Equal("tg-1"))
+				})
+			})
+		})
+	})
+
+	Describe("second-chance placement", func() {
+		var (
+			startAuction auctiontypes.LRPAuction
+			taskAuction  auctiontypes.TaskAuction
+			options      []auctionrunner.SchedulerOption
+		)
+
+		BeforeEach(func() {
+			clients["A-cell"] = &repfakes.FakeSimClient{}
+			clients["B-cell"] = &repfakes.FakeSimClient{}
+			zones["A-zone"] = auctionrunner.Zone{
+				auctionrunner.NewCell(
+					logger,
+					"A-cell",
+					clients["A-cell"],
+					BuildCellState("A-cell", 0, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, nil, []string{}, []string{}, []string{}, 0),
+				),
+				auctionrunner.NewCell(
+					logger,
+					"B-cell",
+					clients["B-cell"],
+					BuildCellState("B-cell", 1, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{
+						*BuildLRP("pg-0", "domain", 0, linuxRootFSURL, 50, 50, 10, []string{}),
+					}, []string{}, []string{}, []string{}, 0),
+				),
+			}
+
+			startAuction = BuildLRPAuction("pg-1", "domain", 0, linuxRootFSURL, 10, 10, 10, clock.Now(), nil, []string{})
+			taskAuction = BuildTaskAuction(BuildTask("tg-1", "domain", linuxRootFSURL, 10, 10, 10, []string{}, []string{}), clock.Now())
+
+			clients["A-cell"].PerformReturnsOnCall(0, rep.Work{
+				LRPs:  []rep.LRP{startAuction.LRP},
+				Tasks: []rep.Task{taskAuction.Task},
+			}, nil)
+
+			options = nil
+		})
+
+		JustBeforeEach(func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0, options...)
+			results = s.Schedule(auctiontypes.AuctionRequest{
+				LRPs:  []auctiontypes.LRPAuction{startAuction},
+				Tasks: []auctiontypes.TaskAuction{taskAuction},
+			})
+		})
+
+		It("fails work refused by a cell by default", func() {
+			Expect(results.FailedLRPs).To(HaveLen(1))
+			Expect(results.FailedTasks).To(HaveLen(1))
+			Expect(clients["B-cell"].PerformCallCount()).To(BeZero())
+		})
+
+		Context("when enabled", func() {
+			BeforeEach(func() {
+				options = append(options, auctionrunner.WithSecondChancePlacement())
+			})
+
+			It("places the refused work on the next best cell", func() {
+				Expect(results.FailedLRPs).To(BeEmpty())
+				Expect(results.FailedTasks).To(BeEmpty())
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].Winner).To(Equal("B-cell"))
+				Expect(results.SuccessfulTasks).To(HaveLen(1))
+				Expect(results.SuccessfulTasks[0].Winner).To(Equal("B-cell"))
+
+				Expect(clients["A-cell"].PerformCallCount()).To(Equal(1))
+				Expect(clients["B-cell"].PerformCallCount()).To(Equal(1))
+				_, work := clients["B-cell"].PerformArgsForCall(0)
+				Expect(work.LRPs).To(ConsistOf(startAuction.LRP))
+				Expect(work.Tasks).To(ConsistOf(taskAuction.Task))
+			})
+
+			Context("when the work is refused again", func() {
+				BeforeEach(func() {
+					clients["B-cell"].PerformReturns(rep.Work{LRPs: []rep.LRP{startAuction.LRP}}, nil)
+				})
+
+				It("reports it as failed", func() {
+					Expect(results.FailedLRPs).To(HaveLen(1))
+					Expect(results.FailedLRPs[0].Attempts).To(Equal(1))
+					Expect(results.SuccessfulTasks).To(HaveLen(1))
+				})
+			})
+
+			Context("when no other cell can take the work", func() {
+				BeforeEach(func() {
+					zones["A-zone"] = zones["A-zone"][:1]
+				})
+
+				It("reports it as failed, saying the cell refused it", func() {
+					Expect(results.FailedLRPs).To(HaveLen(1))
+					Expect(results.FailedLRPs[0].PlacementError).To(Equal("cell refused the work when it was committed"))
+					Expect(results.FailedTasks).To(HaveLen(1))
+					Expect(clients["A-cell"].PerformCallCount()).To(Equal(1))
+				})
+			})
+		})
+	})
+
+	Describe("priorities", func() {
+		BeforeEach(func() {
+			clients["A-cell"] = &repfakes.FakeSimClient{}
+			zones["A-zone"] = auctionrunner.Zone{
+				auctionrunner.NewCell(
+					logger,
+					"A-cell",
+					clients["A-cell"],
+					BuildCellState("A-cell", 0, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, nil, []string{}, []string{}, []string{}, 0),
+				),
+			}
+		})
+
+		It("gives contended capacity to the higher priority lrp", func() {
+			ordinary := BuildLRPAuction("pg-1", "domain", 0, linuxRootFSURL, 60, 60, 10, clock.Now(), nil, []string{})
+			critical := BuildLRPAuction("pg-2", "domain", 1, linuxRootFSURL, 60, 60, 10, clock.Now(), nil, []string{})
+			critical.Priority = 10
+
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0)
+			results = s.Schedule(auctiontypes.AuctionRequest{LRPs: []auctiontypes.LRPAuction{ordinary, critical}})
+
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-2"))
+			Expect(results.FailedLRPs).To(HaveLen(1))
+			Expect(results.FailedLRPs[0].ProcessGuid).To(Equal("pg-1"))
+		})
+
+		It("places a higher priority later instance ahead of lower priority tasks", func() {
+			critical := BuildLRPAuction("pg-1", "domain", 1, linuxRootFSURL, 60, 60, 10, clock.Now(), nil, []string{})
+			critical.Priority = 10
+			task := BuildTaskAuction(BuildTask("tg-1", "domain", linuxRootFSURL, 60, 60, 10, []string{}, []string{}), clock.Now())
+
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0)
+			results = s.Schedule(auctiontypes.AuctionRequest{
+				LRPs:  []auctiontypes.LRPAuction{critical},
+				Tasks: []auctiontypes.TaskAuction{task},
+			})
+
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-1"))
+			Expect(results.FailedTasks).To(HaveLen(1))
+		})
+
+		It("places first instances ahead of tasks and later instances of the same priority", func() {
+			firstInstance := BuildLRPAuction("pg-1", "domain", 0, linuxRootFSURL, 40, 40, 10, clock.Now(), nil, []string{})
+			laterInstance := BuildLRPAuction("pg-2", "domain", 1, linuxRootFSURL, 40, 40, 10, clock.Now(), nil, []string{})
+			task := BuildTaskAuction(BuildTask("tg-1", "domain", linuxRootFSURL, 40, 40, 10, []string{}, []string{}), clock.Now())
+			for _, record := range []*auctiontypes.AuctionRecord{&firstInstance.AuctionRecord, &laterInstance.AuctionRecord, &task.AuctionRecord} {
+				record.Priority = 5
+			}
+
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0)
+			results = s.Schedule(auctiontypes.AuctionRequest{
+				LRPs:  []auctiontypes.LRPAuction{laterInstance, firstInstance},
+				Tasks: []auctiontypes.TaskAuction{task},
+			})
+
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-1"))
+			Expect(results.SuccessfulTasks).To(HaveLen(1))
+			Expect(results.FailedLRPs).To(HaveLen(1))
+			Expect(results.FailedLRPs[0].ProcessGuid).To(Equal("pg-2"))
+		})
+
+		It("places lower priority first instances after higher priority work", func() {
+			firstInstance := BuildLRPAuction("pg-1", "domain", 0, linuxRootFSURL, 40, 40, 10, clock.Now(), nil, []string{})
+			laterInstance := BuildLRPAuction("pg-2", "domain", 1, linuxRootFSURL, 40, 40, 10, clock.Now(), nil, []string{})
+			laterInstance.Priority = 10
+			task := BuildTaskAuction(BuildTask("tg-1", "domain", linuxRootFSURL, 40, 40, 10, []string{}, []string{}), clock.Now())
+			task.Priority = 10
+
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0)
+			results = s.Schedule(auctiontypes.AuctionRequest{
+				LRPs:  []auctiontypes.LRPAuction{firstInstance, laterInstance},
+				Tasks: []auctiontypes.TaskAuction{task},
+			})
+
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-2"))
+			Expect(results.SuccessfulTasks).To(HaveLen(1))
+			Expect(results.FailedLRPs).To(HaveLen(1))
+			Expect(results.FailedLRPs[0].ProcessGuid).To(Equal("pg-1"))
+		})
+
+		It("gives contended capacity to the higher priority task", func() {
+			ordinary := BuildTaskAuction(BuildTask("tg-1", "domain", linuxRootFSURL, 70, 70, 10, []string{}, []string{}), clock.Now())
+			critical := BuildTaskAuction(BuildTask("tg-2", "domain", linuxRootFSURL, 60, 60, 10, []string{}, []string{}), clock.Now())
+			critical.Priority = 10
+
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0)
+			results = s.Schedule(auctiontypes.AuctionRequest{Tasks: []auctiontypes.TaskAuction{ordinary, critical}})
+
+			Expect(results.SuccessfulTasks).To(HaveLen(1))
+			Expect(results.SuccessfulTasks[0].TaskGuid).To(Equal("tg-2"))
+			Expect(results.FailedTasks).To(HaveLen(1))
+			Expect(results.FailedTasks[0].TaskGuid).To(Equal("tg-1"))
+		})
+	})
+
+	Describe("aging", func() {
+		var (
+			large auctiontypes.LRPAuction
+			small auctiontypes.LRPAuction
+		)
+
+		BeforeEach(func() {
+			clients["A-cell"] = &repfakes.FakeSimClient{}
+			zones["A-zone"] = auctionrunner.Zone{
+				auctionrunner.NewCell(
+					logger,
+					"A-cell",
+					clients["A-cell"],
+					BuildCellState("A-cell", 0, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, nil, []string{}, []string{}, []string{}, 0),
+				),
+			}
+
+			large = BuildLRPAuction("pg-1", "domain", 0, linuxRootFSURL, 80, 80, 10, clock.Now(), nil, []string{})
+			small = BuildLRPAuction("pg-2", "domain", 0, linuxRootFSURL, 30, 30, 10, clock.Now().Add(-time.Minute), nil, []string{})
+			small.Attempts = 3
+		})
+
+		It("places larger work first by default", func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0)
+			results = s.Schedule(auctiontypes.AuctionRequest{LRPs: []auctiontypes.LRPAuction{large, small}})
+
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-1"))
+			Expect(results.FailedLRPs[0].AgingBoost).To(Equal(0))
+		})
+
+		It("places work that has waited first, and reports the boost", func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0,
+				auctionrunner.WithAgingPolicy(auctionrunner.AgingPolicy{AttemptsPerBoost: 3}),
+			)
+			results = s.Schedule(auctiontypes.AuctionRequest{LRPs: []auctiontypes.LRPAuction{large, small}})
+
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-2"))
+			Expect(results.SuccessfulLRPs[0].AgingBoost).To(Equal(1))
+			Expect(results.FailedLRPs).To(HaveLen(1))
+			Expect(results.FailedLRPs[0].AgingBoost).To(Equal(0))
+		})
+
+		It("does not put aged work ahead of a higher priority class than the boost reaches", func() {
+			large.Priority = 5
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0,
+				auctionrunner.WithAgingPolicy(auctionrunner.AgingPolicy{WaitPerBoost: time.Second, MaxBoost: 4}),
+			)
+			results = s.Schedule(auctiontypes.AuctionRequest{LRPs: []auctiontypes.LRPAuction{large, small}})
+
+			Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-1"))
+			Expect(results.FailedLRPs[0].AgingBoost).To(Equal(4))
+		})
+
+		It("places aged later instances ahead of newer tasks and first instances", func() {
+			firstInstance := BuildLRPAuction("pg-1", "domain", 0, linuxRootFSURL, 60, 60, 10, clock.Now(), nil, []string{})
+			laterInstance := BuildLRPAuction("pg-2", "domain", 1, linuxRootFSURL, 60, 60, 10, clock.Now().Add(-time.Hour), nil, []string{})
+			task := BuildTaskAuction(BuildTask("tg-1", "domain", linuxRootFSURL, 60, 60, 10, []string{}, []string{}), clock.Now())
+
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0,
+				auctionrunner.WithAgingPolicy(auctionrunner.AgingPolicy{WaitPerBoost: time.Second}),
+			)
+			results = s.Schedule(auctiontypes.AuctionRequest{
+				LRPs:  []auctiontypes.LRPAuction{firstInstance, laterInstance},
+				Tasks: []auctiontypes.TaskAuction{task},
+			})
+
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-2"))
+			Expect(results.SuccessfulLRPs[0].AgingBoost).To(BeNumerically(">", 0))
+			Expect(results.FailedTasks).To(HaveLen(1))
+			Expect(results.FailedLRPs).To(HaveLen(1))
+			Expect(results.FailedLRPs[0].ProcessGuid).To(Equal("pg-1"))
+		})
+
+		It("still places first instances ahead of tasks and later instances aged alike", func() {
+			queueTime := clock.Now().Add(-time.Hour)
+			firstInstance := BuildLRPAuction("pg-1", "domain", 0, linuxRootFSURL, 40, 40, 10, queueTime, nil, []string{})
+			laterInstance := BuildLRPAuction("pg-2", "domain", 1, linuxRootFSURL, 40, 40, 10, queueTime, nil, []string{})
+			task := BuildTaskAuction(BuildTask("tg-1", "domain", linuxRootFSURL, 40, 40, 10, []string{}, []string{}), queueTime)
+
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0,
+				auctionrunner.WithAgingPolicy(auctionrunner.AgingPolicy{WaitPerBoost: time.Second, MaxBoost: 3}),
+			)
+			results = s.Schedule(auctiontypes.AuctionRequest{
+				LRPs:  []auctiontypes.LRPAuction{laterInstance, firstInstance},
+				Tasks: []auctiontypes.TaskAuction{task},
+			})
+
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulLRPs[0].ProcessGuid).To(Equal("pg-1"))
+			Expect(results.SuccessfulTasks).To(HaveLen(1))
+			Expect(results.FailedLRPs).To(HaveLen(1))
+			Expect(results.FailedLRPs[0].ProcessGuid).To(Equal("pg-2"))
+			Expect(results.FailedLRPs[0].AgingBoost).To(Equal(3))
+		})
+	})
+
+	Describe("planning", func() {
+		var (
+			startAuction auctiontypes.LRPAuction
+			bigAuction   auctiontypes.LRPAuction
+			taskAuction  auctiontypes.TaskAuction
+		)
+
+		BeforeEach(func() {
+			clients["A-cell"] = &repfakes.FakeSimClient{}
+			zones["A-zone"] = auctionrunner.Zone{
+				auctionrunner.NewCell(
+					logger,
+					"A-cell",
+					clients["A-cell"],
+					BuildCellState("cellID", 0, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{
+						*BuildLRP("pg-1", "domain", 0, "", 10, 10, 10, []string{}),
+					}, []string{}, []string{}, []string{}, 0),
+				),
+			}
+
+			startAuction = BuildLRPAuction("pg-2", "domain", 0, linuxRootFSURL, 10, 10, 10, clock.Now(), nil, []string{})
+			bigAuction = BuildLRPAuction("pg-3", "domain", 0, linuxRootFSURL, 1000, 10, 10, clock.Now(), nil, []string{})
+			taskAuction = BuildTaskAuction(BuildTask("tg-1", "domain", linuxRootFSURL, 10, 10, 10, []string{}, []string{}), clock.Now())
+
+			clock.Increment(time.Minute)
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0)
+			results = s.Plan(auctiontypes.AuctionRequest{
+				LRPs:  []auctiontypes.LRPAuction{startAuction, bigAuction},
+				Tasks: []auctiontypes.TaskAuction{taskAuction},
+			})
+		})
+
+		It("does not commit any work to the cells", func() {
+			Expect(clients["A-cell"].PerformCallCount()).To(Equal(0))
+		})
+
+		It("returns the proposed placements without marking them as attempted", func() {
+			startAuction.Winner = "A-cell"
+			taskAuction.Winner = "A-cell"
+			Expect(results.SuccessfulLRPs).To(ConsistOf(startAuction))
+			Expect(results.SuccessfulTasks).To(ConsistOf(taskAuction))
+		})
+
+		It("returns the work that could not be placed", func() {
+			Expect(results.FailedLRPs).To(HaveLen(1))
+			Expect(results.FailedLRPs[0].Identifier()).To(Equal(bigAuction.Identifier()))
+			Expect(results.FailedLRPs[0].Attempts).To(Equal(0))
+			Expect(results.FailedLRPs[0].PlacementError).To(ContainSubstring("insufficient resources"))
+		})
+
+		Context("when there are no cells", func() {
+			BeforeEach(func() {
+				s := auctionrunner.NewScheduler(workPool, map[string]auctionrunner.Zone{}, clock, logger, 0.0, 0.0, 0)
+				results = s.Plan(auctiontypes.AuctionRequest{LRPs: []auctiontypes.LRPAuction{startAuction}})
+			})
+
+			It("fails everything without marking it as attempted", func() {
+				Expect(results.FailedLRPs).To(HaveLen(1))
+				Expect(results.FailedLRPs[0].Attempts).To(Equal(0))
+				Expect(results.FailedLRPs[0].PlacementError).To(Equal(auctiontypes.ErrorCellCommunication.Error()))
+			})
+		})
+	})
+
+	Describe("explaining placements", func() {
+		var (
+			startAuction auctiontypes.LRPAuction
+			options      []auctionrunner.SchedulerOption
+		)
+
+		BeforeEach(func() {
+			clients["windows-cell"] = &repfakes.FakeSimClient{}
+			clients["small-cell"] = &repfakes.FakeSimClient{}
+			clients["big-cell"] = &repfakes.FakeSimClient{}
+			zones["A-zone"] = auctionrunner.Zone{
+				auctionrunner.NewCell(
+					logger,
+					"windows-cell",
+					clients["windows-cell"],
+					BuildCellState("windows-cell", 0, "A-zone", 100, 100, 100, false, 0, windowsOnlyRootFSProviders, []rep.LRP{}, []string{}, []string{}, []string{}, 0),
+				),
+				auctionrunner.NewCell(
+					logger,
+					"small-cell",
+					clients["small-cell"],
+					BuildCellState("small-cell", 1, "A-zone", 5, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{}, []string{}, []string{}, []string{}, 0),
+				),
+				auctionrunner.NewCell(
+					logger,
+					"big-cell",
+					clients["big-cell"],
+					BuildCellState("big-cell", 2, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{
+						*BuildLRP("pg-1", "domain", 0, "", 10, 10, 10, []string{}),
+					}, []string{}, []string{}, []string{}, 0),
+				),
+			}
+
+			startAuction = BuildLRPAuction("pg-1", "domain", 1, linuxRootFSURL, 10, 10, 10, clock.Now(), nil, []string{})
+			options = []auctionrunner.SchedulerOption{auctionrunner.WithPlacementExplanations()}
+		})
+
+		JustBeforeEach(func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0, options...)
+			results = s.Schedule(auctiontypes.AuctionRequest{LRPs: []auctiontypes.LRPAuction{startAuction}})
+		})
+
+		It("explains what happened on every candidate cell", func() {
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			explanation := results.SuccessfulLRPs[0].Explanation
+			Expect(explanation).NotTo(BeNil())
+			Expect(explanation.Cells).To(HaveLen(3))
+
+			cells := map[string]auctiontypes.CellExplanation{}
+			for _, cell := range explanation.Cells {
+				cells[cell.CellID] = cell
+			}
+
+			Expect(cells["windows-cell"].Filter).To(Equal("rootfs"))
+			Expect(cells["windows-cell"].Reason).To(Equal(auctiontypes.ErrorCellMismatch.Error()))
+			Expect(cells["windows-cell"].Score).To(BeNil())
+
+			Expect(cells["small-cell"].Filter).To(BeEmpty())
+			Expect(cells["small-cell"].MissingResources).To(Equal([]string{"memory"}))
+			Expect(cells["small-cell"].Score).To(BeNil())
+
+			Expect(cells["big-cell"].Winner).To(BeTrue())
+			Expect(cells["big-cell"].Zone).To(Equal("A-zone"))
+			Expect(cells["big-cell"].Score).NotTo(BeNil())
+			Expect(cells["big-cell"].Score.Locality).To(BeNumerically("==", auctionrunner.LocalityOffset))
+			Expect(cells["big-cell"].Score.Total).To(BeNumerically("==", cells["big-cell"].Score.Resource+cells["big-cell"].Score.Locality+cells["big-cell"].Score.Index))
+		})
+
+		Context("when the work cannot be placed", func() {
+			BeforeEach(func() {
+				startAuction = BuildLRPAuction("pg-1", "domain", 1, linuxRootFSURL, 1000, 10, 10, clock.Now(), nil, []string{})
+			})
+
+			It("explains the failure", func() {
+				Expect(results.FailedLRPs).To(HaveLen(1))
+				explanation := results.FailedLRPs[0].Explanation
+				Expect(explanation).NotTo(BeNil())
+				Expect(explanation.Cells).To(HaveLen(3))
+				for _, cell := range explanation.Cells {
+					Expect(cell.Winner).To(BeFalse())
+					Expect(cell.Reason).NotTo(BeEmpty())
+				}
+			})
+		})
+
+		Context("when explanations are not asked for", func() {
+			BeforeEach(func() {
+				options = nil
+			})
+
+			It("does not attach an explanation", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].Explanation).To(BeNil())
+			})
+		})
+	})
+	Describe("spreading tasks across zones", func() {
+		var (
+			tasks   []auctiontypes.TaskAuction
+			options []auctionrunner.SchedulerOption
+		)
+
+		BeforeEach(func() {
+			for _, zone := range []string{"A-zone", "B-zone"} {
+				guid := zone + "-cell"
+				clients[guid] = &repfakes.FakeSimClient{}
+				zones[zone] = auctionrunner.Zone{
+					auctionrunner.NewCell(logger, guid, clients[guid], BuildCellState("cellID", 0, zone, 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{}, []string{}, []string{}, []string{}, 0)),
+				}
+			}
+
+			tasks = []auctiontypes.TaskAuction{}
+			for _, guid := range []string{"tg-1", "tg-2", "tg-3", "tg-4"} {
+				tasks = append(tasks, BuildTaskAuction(BuildTask(guid, "burst", linuxRootFSURL, 10, 10, 10, []string{}, []string{}), clock.Now()))
+			}
+			options = []auctionrunner.SchedulerOption{auctionrunner.WithScorer(preferCellScorer{cellGuid: "A-zone-cell"})}
+		})
+
+		JustBeforeEach(func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0, options...)
+			results = s.Schedule(auctiontypes.AuctionRequest{Tasks: tasks})
+		})
+
+		winners := func() map[string]int {
+			counts := map[string]int{}
+			for _, task := range results.SuccessfulTasks {
+				counts[task.Winner]++
+			}
+			return counts
+		}
+
+		It("places a burst of tasks on the best scoring cell by default", func() {
+			Expect(winners()).To(Equal(map[string]int{"A-zone-cell": 4}))
+		})
+
+		Context("with task zone spreading", func() {
+			BeforeEach(func() {
+				options = append(options, auctionrunner.WithTaskZoneSpreading(auctionrunner.TaskGroupByDomain{}))
+			})
+
+			It("spreads the tasks of a group across the zones", func() {
+				Expect(winners()).To(Equal(map[string]int{"A-zone-cell": 2, "B-zone-cell": 2}))
+			})
+
+			Context("when a zone already has tasks of another group", func() {
+				BeforeEach(func() {
+					state := BuildCellState("cellID", 0, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{}, []string{}, []string{}, []string{}, 0)
+					state.AddTask(BuildTask("tg-other", "other", linuxRootFSURL, 10, 10, 10, []string{}, []string{}))
+					zones["A-zone"] = auctionrunner.Zone{auctionrunner.NewCell(logger, "A-zone-cell", clients["A-zone-cell"], state)}
+				})
+
+				It("does not count them", func() {
+					Expect(winners()).To(Equal(map[string]int{"A-zone-cell": 2, "B-zone-cell": 2}))
+				})
+			})
+
+			Context("when the zone with the fewest tasks of the group has no room", func() {
+				BeforeEach(func() {
+					options = []auctionrunner.SchedulerOption{auctionrunner.WithTaskZoneSpreading(auctionrunner.TaskGroupByDomain{})}
+					tasks = tasks[:1]
+					zones["B-zone"] = auctionrunner.Zone{
+						auctionrunner.NewCell(logger, "B-zone-cell", clients["B-zone-cell"], BuildCellState("cellID", 0, "B-zone", 5, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{}, []string{}, []string{}, []string{}, 0)),
+					}
+					state := BuildCellState("cellID", 0, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{}, []string{}, []string{}, []string{}, 0)
+					state.AddTask(BuildTask("tg-old", "burst", linuxRootFSURL, 10, 10, 10, []string{}, []string{}))
+					zones["A-zone"] = auctionrunner.Zone{auctionrunner.NewCell(logger, "A-zone-cell", clients["A-zone-cell"], state)}
+				})
+
+				It("places the task in another zone", func() {
+					Expect(winners()).To(Equal(map[string]int{"A-zone-cell": 1}))
+				})
+			})
+
+			Context("when the grouping puts a task in no group", func() {
+				BeforeEach(func() {
+					for i := range tasks {
+						tasks[i].Domain = ""
+					}
+				})
+
+				It("does not spread it", func() {
+					Expect(winners()).To(Equal(map[string]int{"A-zone-cell": 4}))
+				})
+			})
+		})
+	})
+
+	Describe("failure domains", func() {
+		var (
+			startAuction auctiontypes.LRPAuction
+			options      []auctionrunner.SchedulerOption
+		)
+
+		BeforeEach(func() {
+			startAuction = BuildLRPAuction("pg-1", "domain", 0, linuxRootFSURL, 10, 10, 10, clock.Now(), nil, []string{})
+			options = nil
+		})
+
+		JustBeforeEach(func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0, options...)
+			results = s.Schedule(auctiontypes.AuctionRequest{LRPs: []auctiontypes.LRPAuction{startAuction}})
+		})
+
+		Context("when a zone has racks", func() {
+			BeforeEach(func() {
+				AddCellWithInstances(logger, zones, clients, "A-zone", "r1-cell-1", 100, "pg-1", 2, []string{"rack:r1"})
+				AddCellWithInstances(logger, zones, clients, "A-zone", "r1-cell-2", 100, "pg-1", 0, []string{"rack:r1"})
+				AddCellWithInstances(logger, zones, clients, "A-zone", "r2-cell", 100, "pg-1", 0, []string{"rack:r2"})
+				options = []auctionrunner.SchedulerOption{auctionrunner.WithScorer(preferCellScorer{cellGuid: "r1-cell-2"})}
+			})
+
+			It("places the instance on the best scoring cell in the zone", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].Winner).To(Equal("r1-cell-2"))
+			})
+
+			Context("and the racks are failure domains", func() {
+				BeforeEach(func() {
+					options = append(options,
+						auctionrunner.WithFailureDomains(auctionrunner.PlacementTagFailureDomains{Levels: []string{"rack"}}),
+						auctionrunner.WithPlacementExplanations(),
+					)
+				})
+
+				It("places the instance in the rack with the fewest instances", func() {
+					Expect(results.SuccessfulLRPs).To(HaveLen(1))
+					Expect(results.SuccessfulLRPs[0].Winner).To(Equal("r2-cell"))
+				})
+
+				It("explains why the other rack was not considered", func() {
+					reasons := map[string]string{}
+					for _, cell := range results.SuccessfulLRPs[0].Explanation.Cells {
+						reasons[cell.CellID] = cell.Reason
+					}
+					Expect(reasons["r1-cell-2"]).To(Equal("not considered: a failure domain with fewer instances had a suitable cell"))
+				})
+			})
+		})
+
+		Context("with several levels", func() {
+			BeforeEach(func() {
+				AddCellWithInstances(logger, zones, clients, "A-zone", "A-cell", 100, "pg-1", 0, []string{"region:east", "rack:r1"})
+				AddCellWithInstances(logger, zones, clients, "B-zone", "B-cell", 100, "pg-1", 2, []string{"region:east", "rack:r2"})
+				AddCellWithInstances(logger, zones, clients, "C-zone", "C-cell", 100, "pg-1", 1, []string{"region:west", "rack:r3"})
+				options = []auctionrunner.SchedulerOption{
+					auctionrunner.WithFailureDomains(auctionrunner.PlacementTagFailureDomains{Levels: []string{"region", "rack"}}),
+				}
+			})
+
+			It("spreads across the outermost domains first", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].Winner).To(Equal("C-cell"))
+			})
+
+			Context("when the outermost domains are level", func() {
+				BeforeEach(func() {
+					AddCellWithInstances(logger, zones, clients, "C-zone", "C-cell-2", 100, "pg-1", 1, []string{"region:west", "rack:r4"})
+				})
+
+				It("spreads across the domains within them", func() {
+					Expect(results.SuccessfulLRPs).To(HaveLen(1))
+					Expect(results.SuccessfulLRPs[0].Winner).To(Equal("A-cell"))
+				})
+			})
+		})
+
+		Context("with a spread constraint", func() {
+			BeforeEach(func() {
+				AddCellWithInstances(logger, zones, clients, "A-zone", "A-cell", 100, "pg-1", 2, []string{"rack:r1"})
+				AddCellWithInstances(logger, zones, clients, "B-zone", "B-cell-1", 100, "pg-1", 0, []string{"rack:r2"})
+				AddCellWithInstances(logger, zones, clients, "B-zone", "B-cell-2", 100, "pg-1", 1, []string{"rack:r3"})
+				options = []auctionrunner.SchedulerOption{
+					auctionrunner.WithFailureDomains(auctionrunner.PlacementTagFailureDomains{Levels: []string{"rack"}}),
+					auctionrunner.WithScorer(preferCellScorer{cellGuid: "A-cell"}),
+				}
+				startAuction.Constraints.Spread = &auctiontypes.SpreadConstraint{MaxSkew: 1, Mode: auctiontypes.SpreadHard}
+			})
+
+			It("keeps the instance out of zones beyond the maximum skew", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].Winner).To(HavePrefix("B-cell"))
+			})
+
+			It("still spreads across the racks in the zone", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].Winner).To(Equal("B-cell-1"))
+			})
+		})
+
+		Context("with a spread constraint pooling zones that have racks", func() {
+			BeforeEach(func() {
+				AddCellWithInstances(logger, zones, clients, "A-zone", "A-cell-1", 100, "pg-1", 1, []string{"rack:a1"})
+				AddCellWithInstances(logger, zones, clients, "A-zone", "A-cell-2", 100, "pg-1", 0, []string{"rack:a2"})
+				AddCellWithInstances(logger, zones, clients, "B-zone", "B-cell-1", 100, "pg-1", 1, []string{"rack:b1"})
+				AddCellWithInstances(logger, zones, clients, "B-zone", "B-cell-2", 100, "pg-1", 0, []string{"rack:b2"})
+				options = []auctionrunner.SchedulerOption{
+					auctionrunner.WithFailureDomains(auctionrunner.PlacementTagFailureDomains{Levels: []string{"rack"}}),
+				}
+				startAuction.Constraints.Spread = &auctiontypes.SpreadConstraint{MaxSkew: 1}
+			})
+
+			Context("when the best scoring cell is in a rack with an instance", func() {
+				BeforeEach(func() {
+					options = append(options, auctionrunner.WithScorer(preferCellScorer{cellGuid: "A-cell-1"}))
+				})
+
+				It("places the instance in a rack with fewer instances", func() {
+					Expect(results.SuccessfulLRPs).To(HaveLen(1))
+					Expect(results.SuccessfulLRPs[0].Winner).To(BeElementOf("A-cell-2", "B-cell-2"))
+				})
+			})
+
+			Context("when the best scoring cell is in another zone's empty rack", func() {
+				BeforeEach(func() {
+					options = append(options, auctionrunner.WithScorer(preferCellScorer{cellGuid: "B-cell-2"}))
+				})
+
+				It("still pools the zones within the skew", func() {
+					Expect(results.SuccessfulLRPs).To(HaveLen(1))
+					Expect(results.SuccessfulLRPs[0].Winner).To(Equal("B-cell-2"))
+				})
+			})
+		})
+
+		Context("with a spread constraint and regions spanning zones", func() {
+			BeforeEach(func() {
+				AddCellWithInstances(logger, zones, clients, "A-zone", "A-cell-1", 100, "pg-1", 1, []string{"region:east", "rack:a1"})
+				AddCellWithInstances(logger, zones, clients, "A-zone", "A-cell-2", 100, "pg-1", 0, []string{"region:east", "rack:a2"})
+				AddCellWithInstances(logger, zones, clients, "B-zone", "B-cell", 100, "pg-1", 1, []string{"region:west", "rack:b1"})
+				AddCellWithInstances(logger, zones, clients, "C-zone", "C-cell", 100, "pg-1", 1, []string{"region:east", "rack:c1"})
+				options = []auctionrunner.SchedulerOption{
+					auctionrunner.WithFailureDomains(auctionrunner.PlacementTagFailureDomains{Levels: []string{"region", "rack"}}),
+					auctionrunner.WithScorer(preferCellScorer{cellGuid: "A-cell-2"}),
+				}
+				startAuction.Constraints.Spread = &auctiontypes.SpreadConstraint{MaxSkew: 1}
+			})
+
+			It("leaves the spread across zones to the constraint", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].Winner).To(Equal("A-cell-2"))
+			})
+		})
+	})
+
+	Describe("affinity", func() {
+		var (
+			startAuction auctiontypes.LRPAuction
+			taskAuction  auctiontypes.TaskAuction
+			options      []auctionrunner.SchedulerOption
+		)
+
+		BeforeEach(func() {
+			cells := map[string]string{"db-cell": "pg-db", "noisy-cell": "pg-noisy", "empty-cell": ""}
+			zone := auctionrunner.Zone{}
+			for guid, processGuid := range cells {
+				lrps := []rep.LRP{}
+				if processGuid != "" {
+					lrps = append(lrps, *BuildLRP(processGuid, "domain", 0, "", 10, 10, 10, []string{}))
+				}
+				clients[guid] = &repfakes.FakeSimClient{}
+				zone = append(zone, auctionrunner.NewCell(logger, guid, clients[guid], BuildCellState("cellID", len(zone), "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, lrps, []string{}, []string{}, []string{}, 0)))
+			}
+			zones["A-zone"] = zone
+
+			startAuction = BuildLRPAuction("pg-web", "domain", 0, linuxRootFSURL, 10, 10, 10, clock.Now(), nil, []string{})
+			taskAuction = BuildTaskAuction(BuildTask("tg-1", "domain", linuxRootFSURL, 10, 10, 10, []string{}, []string{}), clock.Now())
+			options = []auctionrunner.SchedulerOption{auctionrunner.WithScorer(preferCellScorer{cellGuid: "noisy-cell"})}
+		})
+
+		JustBeforeEach(func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0, options...)
+			results = s.Schedule(auctiontypes.AuctionRequest{
+				LRPs:  []auctiontypes.LRPAuction{startAuction},
+				Tasks: []auctiontypes.TaskAuction{taskAuction},
+			})
+		})
+
+		It("places work on the best scoring cell without rules", func() {
+			Expect(results.SuccessfulLRPs).To(HaveLen(1))
+			Expect(results.SuccessfulLRPs[0].Winner).To(Equal("noisy-cell"))
+			Expect(results.SuccessfulTasks).To(HaveLen(1))
+			Expect(results.SuccessfulTasks[0].Winner).To(Equal("noisy-cell"))
+		})
+
+		Context("with a hard affinity rule", func() {
+			BeforeEach(func() {
+				rules := []auctiontypes.AffinityRule{{ProcessGuids: []string{"pg-other", "pg-db"}, Hard: true}}
+				startAuction.Constraints.Affinity = rules
+				taskAuction.Constraints.Affinity = rules
+			})
+
+			It("places the work with the process", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].Winner).To(Equal("db-cell"))
+				Expect(results.SuccessfulTasks).To(HaveLen(1))
+				Expect(results.SuccessfulTasks[0].Winner).To(Equal("db-cell"))
+			})
+
+			Context("when no cell runs the process", func() {
+				BeforeEach(func() {
+					startAuction.Constraints.Affinity = []auctiontypes.AffinityRule{{ProcessGuids: []string{"pg-other"}, Hard: true}}
+				})
+
+				It("fails the auction with the rule that blocked it", func() {
+					Expect(results.FailedLRPs).To(HaveLen(1))
+					Expect(results.FailedLRPs[0].PlacementError).To(Equal("found no cell satisfying affinity with pg-other"))
+				})
+			})
+		})
+
+		Context("with a hard anti-affinity rule", func() {
+			BeforeEach(func() {
+				rules := []auctiontypes.AffinityRule{{ProcessGuids: []string{"pg-noisy"}, Anti: true, Hard: true}}
+				startAuction.Constraints.Affinity = rules
+				taskAuction.Constraints.Affinity = rules
+			})
+
+			It("keeps the work away from the process", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].Winner).NotTo(Equal("noisy-cell"))
+				Expect(results.SuccessfulTasks).To(HaveLen(1))
+				Expect(results.SuccessfulTasks[0].Winner).NotTo(Equal("noisy-cell"))
+			})
+		})
+
+		Context("with an anti-affinity rule on the LRP itself", func() {
+			var secondAuction auctiontypes.LRPAuction
+
+			BeforeEach(func() {
+				rules := []auctiontypes.AffinityRule{{ProcessGuids: []string{"pg-web"}, Anti: true, Hard: true}}
+				startAuction.Constraints.Affinity = rules
+				secondAuction = BuildLRPAuction("pg-web", "domain", 1, linuxRootFSURL, 10, 10, 10, clock.Now(), nil, []string{})
+				secondAuction.Constraints.Affinity = rules
+			})
+
+			JustBeforeEach(func() {
+				s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0, options...)
+				results = s.Schedule(auctiontypes.AuctionRequest{
+					LRPs: []auctiontypes.LRPAuction{startAuction, secondAuction},
+				})
+			})
+
+			It("places each instance on its own cell", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(2))
+				Expect(results.SuccessfulLRPs[0].Winner).NotTo(Equal(results.SuccessfulLRPs[1].Winner))
+			})
+		})
+
+		Context("with weighted rules", func() {
+			BeforeEach(func() {
+				startAuction.Constraints.Affinity = []auctiontypes.AffinityRule{{ProcessGuids: []string{"pg-db"}, Weight: 10}}
+				taskAuction.Constraints.Affinity = []auctiontypes.AffinityRule{{ProcessGuids: []string{"pg-noisy"}, Anti: true, Weight: 10}}
+				options = append(options, auctionrunner.WithPlacementExplanations())
+			})
+
+			It("prefers cells that satisfy them", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].Winner).To(Equal("db-cell"))
+				Expect(results.SuccessfulTasks).To(HaveLen(1))
+				Expect(results.SuccessfulTasks[0].Winner).NotTo(Equal("noisy-cell"))
+			})
+
+			It("reports what they added to the scores", func() {
+				scores := map[string]float64{}
+				for _, cell := range results.SuccessfulLRPs[0].Explanation.Cells {
+					scores[cell.CellID] = cell.Score.Affinity
+				}
+				Expect(scores).To(Equal(map[string]float64{"db-cell": -10, "noisy-cell": 0, "empty-cell": 0}))
+			})
+
+			Context("when the score difference outweighs them", func() {
+				BeforeEach(func() {
+					startAuction.Constraints.Affinity[0].Weight = 0.5
+				})
+
+				It("places the work on the best scoring cell", func() {
+					Expect(results.SuccessfulLRPs).To(HaveLen(1))
+					Expect(results.SuccessfulLRPs[0].Winner).To(Equal("noisy-cell"))
+				})
+			})
+
+			Context("when a weight is negative", func() {
+				BeforeEach(func() {
+					startAuction.Constraints.Affinity = []auctiontypes.AffinityRule{{ProcessGuids: []string{"pg-other"}, Weight: -10}}
+				})
+
+				It("does not make the rule hard", func() {
+					Expect(results.SuccessfulLRPs).To(HaveLen(1))
+					Expect(results.SuccessfulLRPs[0].Winner).To(Equal("noisy-cell"))
+				})
+			})
+		})
+	})
+
+	Describe("instance caps", func() {
+		var (
+			startAuctions []auctiontypes.LRPAuction
+			options       []auctionrunner.SchedulerOption
+		)
+
+		newAuction := func(index int, constraints auctiontypes.LRPConstraints) auctiontypes.LRPAuction {
+			auction := BuildLRPAuction("pg-1", "domain", index, linuxRootFSURL, 10, 10, 10, clock.Now(), nil, []string{})
+			auction.Constraints = constraints
+			return auction
+		}
+
+		BeforeEach(func() {
+			options = nil
+		})
+
+		JustBeforeEach(func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0, options...)
+			results = s.Schedule(auctiontypes.AuctionRequest{LRPs: startAuctions})
+		})
+
+		Context("per cell", func() {
+			BeforeEach(func() {
+				AddCellWithInstances(logger, zones, clients, "A-zone", "full-cell", 100, "pg-1", 2, []string{})
+				AddCellWithInstances(logger, zones, clients, "A-zone", "other-cell", 100, "pg-1", 0, []string{})
+				options = []auctionrunner.SchedulerOption{auctionrunner.WithScorer(preferCellScorer{cellGuid: "full-cell"})}
+				startAuctions = []auctiontypes.LRPAuction{newAuction(0, auctiontypes.LRPConstraints{})}
+			})
+
+			It("places instances on the best scoring cell without a cap", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].Winner).To(Equal("full-cell"))
+			})
+
+			Context("with a cap", func() {
+				BeforeEach(func() {
+					startAuctions = []auctiontypes.LRPAuction{newAuction(0, auctiontypes.LRPConstraints{MaxInstancesPerCell: 2})}
+				})
+
+				It("keeps instances off cells at the cap", func() {
+					Expect(results.SuccessfulLRPs).To(HaveLen(1))
+					Expect(results.SuccessfulLRPs[0].Winner).To(Equal("other-cell"))
+				})
+
+				Context("when every cell is at the cap", func() {
+					BeforeEach(func() {
+						startAuctions = append(startAuctions, newAuction(1, startAuctions[0].Constraints), newAuction(2, startAuctions[0].Constraints))
+					})
+
+					It("reports the cap as what blocked placement", func() {
+						Expect(results.SuccessfulLRPs).To(HaveLen(2))
+						Expect(results.FailedLRPs).To(HaveLen(1))
+						Expect(results.FailedLRPs[0].PlacementError).To(Equal(auctiontypes.InstanceCapError{Scope: "cell", Max: 2}.Error()))
+					})
+				})
+			})
+		})
+
+		Context("per zone", func() {
+			BeforeEach(func() {
+				AddCellWithInstances(logger, zones, clients, "A-zone", "A-cell", 100, "pg-1", 1, []string{})
+				AddCellWithInstances(logger, zones, clients, "B-zone", "B-cell", 100, "pg-1", 0, []string{})
+				startAuctions = []auctiontypes.LRPAuction{}
+				for i := 0; i < 3; i++ {
+					startAuctions = append(startAuctions, newAuction(i, auctiontypes.LRPConstraints{MaxInstancesPerZone: 2}))
+				}
+			})
+
+			It("places instances until every zone is at the cap", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(3))
+				Expect(results.FailedLRPs).To(BeEmpty())
+			})
+
+			Context("when the zone with room is at the cap", func() {
+				BeforeEach(func() {
+					zones["B-zone"] = nil
+					AddCellWithInstances(logger, zones, clients, "B-zone", "B-cell", 5, "pg-1", 0, []string{})
+					startAuctions = startAuctions[:2]
+				})
+
+				It("reports the cap as what blocked placement", func() {
+					Expect(results.SuccessfulLRPs).To(HaveLen(1))
+					Expect(results.SuccessfulLRPs[0].Winner).To(Equal("A-cell"))
+					Expect(results.FailedLRPs).To(HaveLen(1))
+					Expect(results.FailedLRPs[0].PlacementError).To(Equal(auctiontypes.InstanceCapError{Scope: "zone", Max: 2}.Error()))
+				})
+			})
+
+			Context("with a cap per cell as well", func() {
+				BeforeEach(func() {
+					for i := range startAuctions {
+						startAuctions[i].Constraints.MaxInstancesPerCell = 1
+					}
+				})
+
+				It("enforces both", func() {
+					Expect(results.SuccessfulLRPs).To(HaveLen(1))
+					Expect(results.SuccessfulLRPs[0].Winner).To(Equal("B-cell"))
+					Expect(results.FailedLRPs).To(HaveLen(2))
+				})
+			})
+		})
+	})
+
+	Describe("spread constraints", func() {
+		var (
+			startAuction auctiontypes.LRPAuction
+			options      []auctionrunner.SchedulerOption
+		)
+
+		BeforeEach(func() {
+			options = nil
+			startAuction = BuildLRPAuction("pg-1", "domain", 0, linuxRootFSURL, 10, 10, 10, clock.Now(), nil, []string{})
+		})
+
+		JustBeforeEach(func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0, options...)
+			results = s.Schedule(auctiontypes.AuctionRequest{LRPs: []auctiontypes.LRPAuction{startAuction}})
+		})
+
+		Context("when the only zone with room is beyond the maximum skew", func() {
+			BeforeEach(func() {
+				AddCellWithInstances(logger, zones, clients, "A-zone", "A-zone-cell", 100, "pg-1", 2, []string{})
+				AddCellWithInstances(logger, zones, clients, "B-zone", "B-zone-cell", 5, "pg-1", 0, []string{})
+			})
+
+			Context("in soft mode", func() {
+				BeforeEach(func() {
+					startAuction.Constraints.Spread = &auctiontypes.SpreadConstraint{MaxSkew: 1, Mode: auctiontypes.SpreadSoft}
+				})
+
+				It("places the instance there anyway", func() {
+					Expect(results.SuccessfulLRPs).To(HaveLen(1))
+					Expect(results.SuccessfulLRPs[0].Winner).To(Equal("A-zone-cell"))
+				})
+
+				Context("and DoNotSchedule is set", func() {
+					BeforeEach(func() {
+						startAuction.Constraints.Spread.DoNotSchedule = true
+					})
+
+					It("fails the auction with the constraint that blocked it", func() {
+						Expect(results.SuccessfulLRPs).To(BeEmpty())
+						Expect(results.FailedLRPs).To(HaveLen(1))
+						Expect(results.FailedLRPs[0].PlacementError).To(ContainSubstring("spread constraint (max skew 1, soft, do not schedule)"))
+					})
+				})
+			})
+
+			Context("in hard mode", func() {
+				BeforeEach(func() {
+					startAuction.Constraints.Spread = &auctiontypes.SpreadConstraint{MaxSkew: 1, Mode: auctiontypes.SpreadHard}
+				})
+
+				It("fails the auction with the constraint that blocked it", func() {
+					Expect(results.SuccessfulLRPs).To(BeEmpty())
+					Expect(results.FailedLRPs).To(HaveLen(1))
+					Expect(results.FailedLRPs[0].PlacementError).To(Equal(auctiontypes.SpreadConstraintError{
+						Constraint: auctiontypes.SpreadConstraint{MaxSkew: 1, Mode: auctiontypes.SpreadHard},
+						Reason:     "only zones beyond the maximum skew have room",
+					}.Error()))
+				})
+
+				Context("with placement explanations", func() {
+					BeforeEach(func() {
+						options = []auctionrunner.SchedulerOption{auctionrunner.WithPlacementExplanations()}
+					})
+
+					It("explains why the zone was not considered", func() {
+						explanation := results.FailedLRPs[0].Explanation
+						Expect(explanation).NotTo(BeNil())
+						reasons := map[string]string{}
+						for _, cell := range explanation.Cells {
+							reasons[cell.CellID] = cell.Reason
+						}
+						Expect(reasons["A-zone-cell"]).To(Equal("not considered: an instance here would exceed the spread constraint's maximum skew"))
+					})
+				})
+
+				Context("and the maximum skew allows it", func() {
+					BeforeEach(func() {
+						startAuction.Constraints.Spread.MaxSkew = 3
+					})
+
+					It("places the instance there", func() {
+						Expect(results.SuccessfulLRPs).To(HaveLen(1))
+						Expect(results.SuccessfulLRPs[0].Winner).To(Equal("A-zone-cell"))
+					})
+				})
+			})
+		})
+
+		Context("when no zone has room", func() {
+			BeforeEach(func() {
+				AddCellWithInstances(logger, zones, clients, "A-zone", "A-zone-cell", 25, "pg-1", 2, []string{})
+				AddCellWithInstances(logger, zones, clients, "B-zone", "B-zone-cell", 5, "pg-1", 0, []string{})
+				startAuction.Constraints.Spread = &auctiontypes.SpreadConstraint{MaxSkew: 1, Mode: auctiontypes.SpreadHard}
+			})
+
+			It("reports the missing resources rather than the constraint", func() {
+				Expect(results.FailedLRPs).To(HaveLen(1))
+				Expect(results.FailedLRPs[0].PlacementError).To(ContainSubstring("insufficient resources"))
+			})
+		})
+
+		Context("when several zones are within the maximum skew", func() {
+			BeforeEach(func() {
+				AddCellWithInstances(logger, zones, clients, "A-zone", "A-zone-cell", 100, "pg-1", 1, []string{})
+				AddCellWithInstances(logger, zones, clients, "B-zone", "B-zone-cell", 100, "pg-1", 0, []string{})
+				options = []auctionrunner.SchedulerOption{auctionrunner.WithScorer(preferCellScorer{cellGuid: "A-zone-cell"})}
+			})
+
+			It("places the instance in the zone with the fewest instances without a constraint", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].Winner).To(Equal("B-zone-cell"))
+			})
+
+			Context("with a constraint", func() {
+				BeforeEach(func() {
+					startAuction.Constraints.Spread = &auctiontypes.SpreadConstraint{MaxSkew: 2, Mode: auctiontypes.SpreadHard}
+				})
+
+				It("places the instance on the best scoring cell among them", func() {
+					Expect(results.SuccessfulLRPs).To(HaveLen(1))
+					Expect(results.SuccessfulLRPs[0].Winner).To(Equal("A-zone-cell"))
+				})
+			})
+		})
+
+		Context("when the zones are already beyond the maximum skew", func() {
+			BeforeEach(func() {
+				AddCellWithInstances(logger, zones, clients, "A-zone", "A-zone-cell", 100, "pg-1", 3, []string{})
+				AddCellWithInstances(logger, zones, clients, "B-zone", "B-zone-cell", 100, "pg-1", 0, []string{})
+				AddCellWithInstances(logger, zones, clients, "C-zone", "C-zone-cell", 100, "pg-1", 0, []string{})
+				startAuction.Constraints.Spread = &auctiontypes.SpreadConstraint{MaxSkew: 1, Mode: auctiontypes.SpreadHard}
+			})
+
+			It("places the instance in an emptier zone", func() {
+				Expect(results.SuccessfulLRPs).To(HaveLen(1))
+				Expect(results.SuccessfulLRPs[0].Winner).To(Or(Equal("B-zone-cell"), Equal("C-zone-cell")))
+			})
+
+			Context("and DoNotSchedule is set", func() {
+				BeforeEach(func() {
+					startAuction.Constraints.Spread.DoNotSchedule = true
+				})
+
+				It("still places the instance in an emptier zone", func() {
+					Expect(results.SuccessfulLRPs).To(HaveLen(1))
+					Expect(results.SuccessfulLRPs[0].Winner).To(Or(Equal("B-zone-cell"), Equal("C-zone-cell")))
+				})
+
+				Context("when the emptier zones have no room", func() {
+					BeforeEach(func() {
+						zones["B-zone"] = nil
+						zones["C-zone"] = nil
+						AddCellWithInstances(logger, zones, clients, "B-zone", "B-zone-cell", 5, "pg-1", 0, []string{})
+						AddCellWithInstances(logger, zones, clients, "C-zone", "C-zone-cell", 5, "pg-1", 0, []string{})
+					})
+
+					It("fails the auction with the constraint that blocked it", func() {
+						Expect(results.SuccessfulLRPs).To(BeEmpty())
+						Expect(results.FailedLRPs).To(HaveLen(1))
+						Expect(results.FailedLRPs[0].PlacementError).To(ContainSubstring("only zones beyond the maximum skew have room"))
+					})
+				})
+			})
+		})
+	})
 })
 
 func setLRPWinner(cellName string, lrps ...*auctiontypes.LRPAuction) {
@@ -1163,3 +2598,52 @@ func setTaskWinner(cellName string, tasks ...*auctiontypes.TaskAuction) {
 		t.Attempts++
 	}
 }
+
+type preferCellScorer struct {
+	cellGuid  string
+	rejectAll bool
+}
+
+func (p preferCellScorer) ScoreForLRP(cell *auctionrunner.Cell, lrp *rep.LRP, startingContainerWeight, binPackFirstFitWeight float64) (float64, error) {
+	if p.rejectAll {
+		return 0, rep.InsufficientResourcesError{Problems: map[string]struct{}{"memory": {}}}
+	}
+	if cell.Guid == p.cellGuid {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+func (p preferCellScorer) ScoreForTask(cell *auctionrunner.Cell, task *rep.Task, startingContainerWeight float64) (float64, error) {
+	if p.rejectAll {
+		return 0, rep.InsufficientResourcesError{Problems: map[string]struct{}{"memory": {}}}
+	}
+	if cell.Guid == p.cellGuid {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+var errCellInMaintenance = errors.New("cell is in maintenance")
+
+type maintenanceFilter struct {
+	cells    map[string]bool
+	lrpsSeen int
+}
+
+func (f *maintenanceFilter) Name() string { return "maintenance" }
+
+func (f *maintenanceFilter) FilterLRP(cell *auctionrunner.Cell, lrp *rep.LRP) error {
+	f.lrpsSeen++
+	if f.cells[cell.Guid] {
+		return errCellInMaintenance
+	}
+	return nil
+}
+
+func (f *maintenanceFilter) FilterTask(cell *auctionrunner.Cell, task *rep.Task) error {
+	if f.cells[cell.Guid] {
+		return errCellInMaintenance
+	}
+	return nil
+}
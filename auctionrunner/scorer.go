@@ -0,0 +1,43 @@
+package auctionrunner
+
+import (
+	"code.cloudfoundry.org/auction/auctiontypes"
+	"code.cloudfoundry.org/rep"
+)
+
+// Scorer decides how desirable a cell is for a piece of work. The scheduler
+// places work on the cell with the lowest score; an error means the cell
+// cannot take the work at all.
+type Scorer interface {
+	ScoreForLRP(cell *Cell, lrp *rep.LRP, startingContainerWeight, binPackFirstFitWeight float64) (float64, error)
+	ScoreForTask(cell *Cell, task *rep.Task, startingContainerWeight float64) (float64, error)
+}
+
+// BreakdownScorer is implemented by Scorers that can say how a score is made
+// up. When placements are explained, the scheduler uses it in place of the
+// plain Scorer methods so explanations carry the full breakdown.
+type BreakdownScorer interface {
+	Scorer
+	LRPScoreBreakdown(cell *Cell, lrp *rep.LRP, startingContainerWeight, binPackFirstFitWeight float64) (auctiontypes.ScoreBreakdown, error)
+	TaskScoreBreakdown(cell *Cell, task *rep.Task, startingContainerWeight float64) (auctiontypes.ScoreBreakdown, error)
+}
+
+// DefaultScorer scores cells by resource usage, locality and, when bin
+// packing is enabled, cell index.
+type DefaultScorer struct{}
+
+func (DefaultScorer) ScoreForLRP(cell *Cell, lrp *rep.LRP, startingContainerWeight, binPackFirstFitWeight float64) (float64, error) {
+	return cell.ScoreForLRP(lrp, startingContainerWeight, binPackFirstFitWeight)
+}
+
+func (DefaultScorer) ScoreForTask(cell *Cell, task *rep.Task, startingContainerWeight float64) (float64, error) {
+	return cell.ScoreForTask(task, startingContainerWeight)
+}
+
+func (DefaultScorer) LRPScoreBreakdown(cell *Cell, lrp *rep.LRP, startingContainerWeight, binPackFirstFitWeight float64) (auctiontypes.ScoreBreakdown, error) {
+	return cell.LRPScoreBreakdown(lrp, startingContainerWeight, binPackFirstFitWeight)
+}
+
+func (DefaultScorer) TaskScoreBreakdown(cell *Cell, task *rep.Task, startingContainerWeight float64) (auctiontypes.ScoreBreakdown, error) {
+	return cell.TaskScoreBreakdown(task, startingContainerWeight)
+}
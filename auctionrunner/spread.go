@@ -0,0 +1,72 @@
+package auctionrunner
+
+import (
+	"code.cloudfoundry.org/auction/auctiontypes"
+)
+
+const reasonZoneExceedsMaxSkew = "not considered: an instance here would exceed the spread constraint's maximum skew"
+
+// applySpreadConstraint returns the zones an LRP may be placed in under its
+// spread constraint, in the order they should be tried, and the zones it may
+// not be placed in.  The zones within the skew are pooled ahead of the rest,
+// so that the best scoring cell among them wins.
+func applySpreadConstraint(constraint auctiontypes.SpreadConstraint, zones []lrpByZone) ([]lrpByZone, []lrpByZone) {
+	maxSkew := constraint.MaxSkew
+	if maxSkew < 1 {
+		maxSkew = 1
+	}
+
+	allowed := []lrpByZone{}
+	excluded := []lrpByZone{}
+	for i := range zones {
+		if zoneWithinSkew(zones, i, maxSkew, constraint.DoNotSchedule) {
+			allowed = append(allowed, lrpByZone{zone: zones[i].zone, instances: -1})
+		} else if constraint.Mode == auctiontypes.SpreadSoft && !constraint.DoNotSchedule {
+			allowed = append(allowed, zones[i])
+		} else {
+			excluded = append(excluded, zones[i])
+		}
+	}
+
+	return allowed, excluded
+}
+
+// zoneWithinSkew reports whether one more instance in the given zone keeps
+// within the skew.  With strict set, the spread across all the zones must
+// not end up wider than the skew, or than it already was, so that zones
+// already too far apart can still be brought back together.
+func zoneWithinSkew(zones []lrpByZone, zoneIndex int, maxSkew int, strict bool) bool {
+	fewest, most := zones[zoneIndex].instances, zones[zoneIndex].instances
+	for i := range zones {
+		if zones[i].instances < fewest {
+			fewest = zones[i].instances
+		}
+		if zones[i].instances > most {
+			most = zones[i].instances
+		}
+	}
+	if zones[zoneIndex].instances+1-fewest > maxSkew {
+		return false
+	}
+	if !strict {
+		return true
+	}
+
+	fewestAfter, mostAfter := zones[zoneIndex].instances+1, zones[zoneIndex].instances+1
+	for i := range zones {
+		if i == zoneIndex {
+			continue
+		}
+		if zones[i].instances < fewestAfter {
+			fewestAfter = zones[i].instances
+		}
+		if zones[i].instances > mostAfter {
+			mostAfter = zones[i].instances
+		}
+	}
+	allowedSpread := maxSkew
+	if most-fewest > allowedSpread {
+		allowedSpread = most - fewest
+	}
+	return mostAfter-fewestAfter <= allowedSpread
+}
@@ -0,0 +1,142 @@
+package auctionrunner
+
+import (
+	"sort"
+	"sync"
+
+	"code.cloudfoundry.org/auction/auctiontypes"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/rep"
+)
+
+type stopCandidate struct {
+	zone string
+	cell *Cell
+	lrp  rep.LRP
+}
+
+/*
+ScheduleStop picks count running instances of the given process and stops
+them.  Instances are taken from the zone running the most instances, so the
+process stays balanced across zones, and within that zone from the most
+loaded cell.  ScheduleStop returns ErrorNothingToStop if no instance of the
+process is running.  Asking for no instances stops nothing.
+*/
+func (s *Scheduler) ScheduleStop(processGuid string, count int) (auctiontypes.LRPStopResults, error) {
+	results := auctiontypes.LRPStopResults{}
+	if count <= 0 {
+		return results, nil
+	}
+
+	candidates := s.stopCandidates(processGuid)
+	if len(candidates) == 0 {
+		return results, auctiontypes.ErrorNothingToStop
+	}
+
+	chosen := []stopCandidate{}
+	for len(chosen) < count && len(candidates) > 0 {
+		best := pickStopCandidate(candidates)
+		candidate := candidates[best]
+		candidates = append(candidates[:best], candidates[best+1:]...)
+
+		candidate.cell.ReleaseLRP(&candidate.lrp)
+		chosen = append(chosen, candidate)
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(len(chosen))
+	lock := &sync.Mutex{}
+
+	for _, candidate := range chosen {
+		candidate := candidate
+		s.workPool.Submit(func() {
+			defer wg.Done()
+			stop := auctiontypes.LRPStop{LRP: candidate.lrp, CellID: candidate.cell.Guid}
+			err := candidate.cell.StopLRP(&candidate.lrp)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				stop.StopError = err.Error()
+				results.FailedStops = append(results.FailedStops, stop)
+				return
+			}
+			s.logger.Info("lrp-stopped-on-cell", lager.Data{"lrp-guid": candidate.lrp.Identifier(), "cell-guid": candidate.cell.Guid})
+			results.SuccessfulStops = append(results.SuccessfulStops, stop)
+		})
+	}
+
+	wg.Wait()
+	return results, nil
+}
+
+func (s *Scheduler) stopCandidates(processGuid string) []stopCandidate {
+	candidates := []stopCandidate{}
+
+	zoneNames := make([]string, 0, len(s.zones))
+	for name := range s.zones {
+		zoneNames = append(zoneNames, name)
+	}
+	sort.Strings(zoneNames)
+
+	for _, name := range zoneNames {
+		for _, cell := range s.zones[name] {
+			for _, lrp := range cell.state.LRPs {
+				if lrp.ProcessGuid == processGuid {
+					candidates = append(candidates, stopCandidate{zone: name, cell: cell, lrp: lrp})
+				}
+			}
+		}
+	}
+
+	return candidates
+}
+
+// pickStopCandidate returns the index of the instance that should be stopped
+// next: one in the zone with the most remaining instances, on the most
+// loaded cell, preferring the highest index.
+func pickStopCandidate(candidates []stopCandidate) int {
+	instancesPerZone := map[string]int{}
+	instancesPerCell := map[string]int{}
+	for _, candidate := range candidates {
+		instancesPerZone[candidate.zone]++
+		instancesPerCell[candidate.cell.Guid]++
+	}
+
+	best := 0
+	for i := 1; i < len(candidates); i++ {
+		a, b := candidates[i], candidates[best]
+
+		if instancesPerZone[a.zone] != instancesPerZone[b.zone] {
+			if instancesPerZone[a.zone] > instancesPerZone[b.zone] {
+				best = i
+			}
+			continue
+		}
+
+		loadA, loadB := cellLoad(a.cell), cellLoad(b.cell)
+		if loadA != loadB {
+			if loadA > loadB {
+				best = i
+			}
+			continue
+		}
+
+		if instancesPerCell[a.cell.Guid] != instancesPerCell[b.cell.Guid] {
+			if instancesPerCell[a.cell.Guid] > instancesPerCell[b.cell.Guid] {
+				best = i
+			}
+			continue
+		}
+
+		if a.lrp.Index > b.lrp.Index {
+			best = i
+		}
+	}
+
+	return best
+}
+
+func cellLoad(cell *Cell) float64 {
+	return cell.state.AvailableResources.ComputeScore(&cell.state.TotalResources)
+}
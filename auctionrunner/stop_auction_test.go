@@ -0,0 +1,161 @@
+package auctionrunner_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/workpool"
+
+	"code.cloudfoundry.org/auction/auctionrunner"
+	"code.cloudfoundry.org/auction/auctiontypes"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/repfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stop Auctions", func() {
+	var clients map[string]*repfakes.FakeSimClient
+	var zones map[string]auctionrunner.Zone
+	var clock *fakeclock.FakeClock
+	var workPool *workpool.WorkPool
+	var logger *lagertest.TestLogger
+	var results auctiontypes.LRPStopResults
+	var err error
+
+	stoppedInstances := func() map[string][]int32 {
+		stopped := map[string][]int32{}
+		for guid, client := range clients {
+			for i := 0; i < client.StopLRPInstanceCallCount(); i++ {
+				_, key, instanceKey := client.StopLRPInstanceArgsForCall(i)
+				Expect(instanceKey.CellId).To(Equal(guid))
+				stopped[guid] = append(stopped[guid], key.Index)
+			}
+		}
+		return stopped
+	}
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+
+		workPool, err = workpool.NewWorkPool(5)
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("fakelogger")
+
+		clients = map[string]*repfakes.FakeSimClient{
+			"A-busy-cell": {},
+			"A-idle-cell": {},
+			"B-cell":      {},
+		}
+
+		zones = map[string]auctionrunner.Zone{
+			"A-zone": {
+				auctionrunner.NewCell(logger, "A-busy-cell", clients["A-busy-cell"], BuildCellState("A-busy-cell", 0, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{
+					*BuildLRP("pg-1", "domain", 0, "", 10, 10, 10, []string{}),
+					*BuildLRP("pg-1", "domain", 2, "", 10, 10, 10, []string{}),
+					*BuildLRP("pg-other", "domain", 0, "", 50, 50, 10, []string{}),
+				}, []string{}, []string{}, []string{}, 0)),
+				auctionrunner.NewCell(logger, "A-idle-cell", clients["A-idle-cell"], BuildCellState("A-idle-cell", 1, "A-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{
+					*BuildLRP("pg-1", "domain", 3, "", 10, 10, 10, []string{}),
+				}, []string{}, []string{}, []string{}, 0)),
+			},
+			"B-zone": {
+				auctionrunner.NewCell(logger, "B-cell", clients["B-cell"], BuildCellState("B-cell", 0, "B-zone", 100, 100, 100, false, 0, linuxOnlyRootFSProviders, []rep.LRP{
+					*BuildLRP("pg-1", "domain", 1, "", 10, 10, 10, []string{}),
+				}, []string{}, []string{}, []string{}, 0)),
+			},
+		}
+	})
+
+	AfterEach(func() {
+		workPool.Stop()
+	})
+
+	Context("when stopping a single instance", func() {
+		BeforeEach(func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0)
+			results, err = s.ScheduleStop("pg-1", 1)
+		})
+
+		It("stops an instance in the zone with the most instances, on the most loaded cell", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stoppedInstances()).To(Equal(map[string][]int32{"A-busy-cell": {2}}))
+			Expect(results.SuccessfulStops).To(HaveLen(1))
+			Expect(results.SuccessfulStops[0].CellID).To(Equal("A-busy-cell"))
+			Expect(results.FailedStops).To(BeEmpty())
+		})
+	})
+
+	Context("when stopping several instances", func() {
+		BeforeEach(func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0)
+			results, err = s.ScheduleStop("pg-1", 2)
+		})
+
+		It("keeps the remaining instances balanced across zones", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results.SuccessfulStops).To(HaveLen(2))
+
+			stopped := stoppedInstances()
+			Expect(stopped).NotTo(HaveKey("B-cell"))
+			Expect(stopped["A-busy-cell"]).To(ContainElement(int32(2)))
+		})
+	})
+
+	Context("when asked to stop more instances than are running", func() {
+		BeforeEach(func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0)
+			results, err = s.ScheduleStop("pg-1", 10)
+		})
+
+		It("stops every running instance", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results.SuccessfulStops).To(HaveLen(4))
+		})
+	})
+
+	Context("when a cell fails to stop an instance", func() {
+		BeforeEach(func() {
+			clients["A-busy-cell"].StopLRPInstanceReturns(errors.New("boom"))
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0)
+			results, err = s.ScheduleStop("pg-1", 1)
+		})
+
+		It("reports the failed stop", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results.SuccessfulStops).To(BeEmpty())
+			Expect(results.FailedStops).To(HaveLen(1))
+			Expect(results.FailedStops[0].StopError).To(Equal("boom"))
+		})
+	})
+
+	Context("when asked to stop no instances", func() {
+		BeforeEach(func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0)
+			results, err = s.ScheduleStop("pg-1", 0)
+		})
+
+		It("stops nothing", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results.SuccessfulStops).To(BeEmpty())
+			Expect(results.FailedStops).To(BeEmpty())
+			Expect(stoppedInstances()).To(BeEmpty())
+		})
+	})
+
+	Context("when no instances of the process are running", func() {
+		BeforeEach(func() {
+			s := auctionrunner.NewScheduler(workPool, zones, clock, logger, 0.0, 0.0, 0)
+			results, err = s.ScheduleStop("pg-missing", 1)
+		})
+
+		It("returns ErrorNothingToStop", func() {
+			Expect(err).To(Equal(auctiontypes.ErrorNothingToStop))
+			Expect(stoppedInstances()).To(BeEmpty())
+		})
+	})
+})
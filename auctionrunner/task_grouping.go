@@ -0,0 +1,19 @@
+package auctionrunner
+
+import (
+	"code.cloudfoundry.org/rep"
+)
+
+// TaskGrouping puts tasks in groups whose members the scheduler spreads
+// across zones, as it does the instances of an LRP.  Tasks in the empty
+// group are not spread.
+type TaskGrouping interface {
+	TaskGroup(task *rep.Task) string
+}
+
+// TaskGroupByDomain spreads the tasks of each domain across zones.
+type TaskGroupByDomain struct{}
+
+func (TaskGroupByDomain) TaskGroup(task *rep.Task) string {
+	return task.Domain
+}
@@ -3,10 +3,13 @@ package auctionrunner_test
 import (
 	"time"
 
+	"code.cloudfoundry.org/auction/auctionrunner"
 	"code.cloudfoundry.org/auction/auctiontypes"
 	"code.cloudfoundry.org/auctioneer"
 	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/repfakes"
 	. "github.com/onsi/gomega"
 )
 
@@ -120,6 +123,20 @@ func BuildTaskAuction(task *rep.Task, queueTime time.Time) auctiontypes.TaskAuct
 	return auctiontypes.NewTaskAuction(*task, queueTime)
 }
 
+func TracedLRPAuctions(traceID string, auctions ...auctiontypes.LRPAuction) []auctiontypes.LRPAuction {
+	for i := range auctions {
+		auctions[i].TraceID = traceID
+	}
+	return auctions
+}
+
+func TracedTaskAuctions(traceID string, auctions ...auctiontypes.TaskAuction) []auctiontypes.TaskAuction {
+	for i := range auctions {
+		auctions[i].TraceID = traceID
+	}
+	return auctions
+}
+
 const linuxStack = "linux"
 
 var linuxRootFSURL = models.PreloadedRootFS(linuxStack)
@@ -177,3 +194,55 @@ func BuildCellState(
 		proxyMemoryAllocationMB,
 	)
 }
+
+// AddCellWithInstances adds a cell running instances of processGuid to the
+// zone, and its fake client to clients.  The cell has room for 100 MB of
+// disk and 100 containers.
+func AddCellWithInstances(
+	logger lager.Logger,
+	zones map[string]auctionrunner.Zone,
+	clients map[string]*repfakes.FakeSimClient,
+	zone string,
+	guid string,
+	memoryMB int32,
+	processGuid string,
+	instances int,
+	optionalPlacementTags []string,
+) {
+	cellIndex := len(zones[zone])
+	lrps := []rep.LRP{}
+	for i := 0; i < instances; i++ {
+		lrps = append(lrps, *BuildLRP(processGuid, "domain", 10+cellIndex*10+i, "", 10, 10, 10, []string{}))
+	}
+
+	clients[guid] = &repfakes.FakeSimClient{}
+	state := BuildCellState("cellID", cellIndex, zone, memoryMB, 100, 100, false, 0, linuxOnlyRootFSProviders, lrps, []string{}, []string{}, optionalPlacementTags, 0)
+	zones[zone] = append(zones[zone], auctionrunner.NewCell(logger, guid, clients[guid], state))
+}
+
+// domainPriorities is a PriorityClassifier that gives work the priority of
+// its domain.
+type domainPriorities map[string]int
+
+func (p domainPriorities) LRPPriority(start *auctioneer.LRPStartRequest) int {
+	return p[start.Domain]
+}
+
+func (p domainPriorities) TaskPriority(task *auctioneer.TaskStartRequest) int {
+	return p[task.Domain]
+}
+
+// guidConstraints is a ConstraintProvider that gives LRPs the constraints of
+// their process guid, and tasks those of their task guid.
+type guidConstraints struct {
+	lrps  map[string]auctiontypes.LRPConstraints
+	tasks map[string]auctiontypes.TaskConstraints
+}
+
+func (c guidConstraints) LRPConstraints(start *auctioneer.LRPStartRequest) auctiontypes.LRPConstraints {
+	return c.lrps[start.ProcessGuid]
+}
+
+func (c guidConstraints) TaskConstraints(task *auctioneer.TaskStartRequest) auctiontypes.TaskConstraints {
+	return c.tasks[task.TaskGuid]
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"code.cloudfoundry.org/auction/auctiontypes"
+	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/rep"
 	"code.cloudfoundry.org/workpool"
@@ -14,25 +15,37 @@ import (
 const MinBinPackFirstFitWeight = 0.0
 
 func FetchStateAndBuildZones(logger lager.Logger, workPool *workpool.WorkPool, clients map[string]rep.Client, metricEmitter auctiontypes.AuctionMetricEmitterDelegate, binPackFirstFitWeight float64) map[string]Zone {
+	return FetchStateAndBuildZonesWithRetry(logger, clock.NewClock(), defaultStateFetchRetryPolicy, PullCellStateSource{}, nil, workPool, clients, metricEmitter, binPackFirstFitWeight)
+}
+
+// FetchStateAndBuildZonesWithRetry fetches the cells' state again, as the
+// policy allows, while no cell answers.  The states come from source; if
+// cache is not nil, cells with a fresh enough cached state are skipped.
+func FetchStateAndBuildZonesWithRetry(logger lager.Logger, clk clock.Clock, retryPolicy RetryPolicy, source CellStateSource, cache *CellStateCache, workPool *workpool.WorkPool, clients map[string]rep.Client, metricEmitter auctiontypes.AuctionMetricEmitterDelegate, binPackFirstFitWeight float64) map[string]Zone {
+	if len(clients) == 0 {
+		return map[string]Zone{}
+	}
+
 	var zones map[string]Zone
-	for i := 0; ; i++ {
-		zones = fetchStateAndBuildZones(logger, workPool, clients, metricEmitter, binPackFirstFitWeight)
-		if len(zones) > 0 {
-			break
-		}
-		if i == 3 {
-			logger.Info("failed-to-communicate-to-cells-abort")
-			break
+	err := retryPolicy.Do(clk, func() error {
+		zones = fetchStateAndBuildZones(logger, source, cache, workPool, clients, metricEmitter, binPackFirstFitWeight)
+		if len(zones) == 0 {
+			logger.Info("failed-to-communicate-to-cells")
+			return auctiontypes.ErrorCellCommunication
 		}
-		logger.Info("failed-to-communicate-to-cells-retry")
+		return nil
+	})
+	if err != nil {
+		logger.Info("failed-to-communicate-to-cells-abort")
 	}
 	return zones
 }
 
-func fetchStateAndBuildZones(logger lager.Logger, workPool *workpool.WorkPool, clients map[string]rep.Client, metricEmitter auctiontypes.AuctionMetricEmitterDelegate, binPackFirstFitWeight float64) map[string]Zone {
+func fetchStateAndBuildZones(logger lager.Logger, source CellStateSource, cache *CellStateCache, workPool *workpool.WorkPool, clients map[string]rep.Client, metricEmitter auctiontypes.AuctionMetricEmitterDelegate, binPackFirstFitWeight float64) map[string]Zone {
 	wg := &sync.WaitGroup{}
 	zones := map[string]Zone{}
 	lock := &sync.Mutex{}
+	cacheHits, cacheMisses := 0, 0
 
 	wg.Add(len(clients))
 	for guid, client := range clients {
@@ -41,8 +54,28 @@ func fetchStateAndBuildZones(logger lager.Logger, workPool *workpool.WorkPool, c
 			defer wg.Done()
 
 			startTime := time.Now()
-			state, err := client.State(logger)
+			if cache != nil {
+				state, ok := cache.get(guid)
+
+				lock.Lock()
+				if ok {
+					cacheHits++
+				} else {
+					cacheMisses++
+				}
+				lock.Unlock()
+
+				if ok {
+					addCell(logger, zones, lock, guid, client, state, startTime)
+					return
+				}
+			}
+
+			state, err := source.CellState(logger, guid, client)
 			if err != nil {
+				if cache != nil {
+					cache.Invalidate(guid)
+				}
 				metricErr := metricEmitter.FailedCellStateRequest()
 				if metricErr != nil {
 					logger.Debug("failed-to-emit-get-cell-state-failure-metric", lager.Data{"error": err})
@@ -51,27 +84,28 @@ func fetchStateAndBuildZones(logger lager.Logger, workPool *workpool.WorkPool, c
 				return
 			}
 
-			if state.Evacuating {
-				logger.Info("ignored-evacuating-cell", lager.Data{"cell-guid": guid, "duration_ns": time.Since(startTime)})
-				return
-			}
-
 			if state.CellID != "" && state.CellID != guid {
 				logger.Error("cell-id-mismatch", nil, lager.Data{"cell-guid": guid, "cell-state-guid": state.CellID, "duration_ns": time.Since(startTime)})
 				return
 			}
 
-			cell := NewCell(logger, guid, client, state)
-
-			lock.Lock()
-			zones[state.Zone] = append(zones[state.Zone], cell)
-			lock.Unlock()
-			logger.Debug("fetched-cell-state", lager.Data{"cell-guid": guid, "duration_ns": time.Since(startTime)})
+			if cache != nil {
+				cache.put(guid, state)
+			}
+			addCell(logger, zones, lock, guid, client, state, startTime)
 		})
 	}
 
 	wg.Wait()
 
+	if cache != nil {
+		logger.Info("cell-state-cache", lager.Data{"hits": cacheHits, "misses": cacheMisses})
+		err := metricEmitter.CellStateCacheHits(cacheHits, cacheMisses)
+		if err != nil {
+			logger.Debug("failed-to-emit-cell-state-cache-metric", lager.Data{"error": err})
+		}
+	}
+
 	if isBinPackFirstFitWeightProvided(binPackFirstFitWeight) {
 		return normaliseCellIndices(zones)
 	}
@@ -79,6 +113,20 @@ func fetchStateAndBuildZones(logger lager.Logger, workPool *workpool.WorkPool, c
 	return zones
 }
 
+func addCell(logger lager.Logger, zones map[string]Zone, lock *sync.Mutex, guid string, client rep.Client, state rep.CellState, startTime time.Time) {
+	if state.Evacuating {
+		logger.Info("ignored-evacuating-cell", lager.Data{"cell-guid": guid, "duration_ns": time.Since(startTime)})
+		return
+	}
+
+	cell := NewCell(logger, guid, client, state)
+
+	lock.Lock()
+	zones[state.Zone] = append(zones[state.Zone], cell)
+	lock.Unlock()
+	logger.Debug("fetched-cell-state", lager.Data{"cell-guid": guid, "duration_ns": time.Since(startTime)})
+}
+
 func isBinPackFirstFitWeightProvided(binPackFirstFitWeight float64) bool {
 	return binPackFirstFitWeight > MinBinPackFirstFitWeight
 }
@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"code.cloudfoundry.org/auction/auctionrunner"
+	"code.cloudfoundry.org/auction/auctiontypes"
 	"code.cloudfoundry.org/auction/auctiontypes/fakes"
+	"code.cloudfoundry.org/clock/fakeclock"
 	"code.cloudfoundry.org/lager/v3/lagertest"
 	"code.cloudfoundry.org/rep"
 	"code.cloudfoundry.org/rep/repfakes"
@@ -282,6 +284,120 @@ var _ = Describe("ZoneBuilder", func() {
 		})
 	})
 
+	Context("when no cell answers", func() {
+		BeforeEach(func() {
+			for _, client := range []*repfakes.FakeSimClient{repA, repB, repC} {
+				client.StateReturns(rep.CellState{}, errors.New("boom"))
+			}
+		})
+
+		It("tries four times by default", func() {
+			zones := auctionrunner.FetchStateAndBuildZones(logger, workPool, clients, metricEmitter, binPackFirstFitWeight)
+			Expect(zones).To(BeEmpty())
+			Expect(repA.StateCallCount()).To(Equal(4))
+			Expect(logger.LogMessages()).To(ContainElement("test.failed-to-communicate-to-cells-abort"))
+		})
+
+		It("retries as the retry policy says, waiting on the clock", func() {
+			clock := fakeclock.NewFakeClock(time.Now())
+			policy := auctionrunner.RetryPolicy{MaxAttempts: 2, InitialDelay: time.Second}
+
+			repA.StateReturnsOnCall(1, BuildCellState("A", 0, "the-zone", 100, 200, 100, false, 0, linuxOnlyRootFSProviders, nil, []string{}, []string{}, []string{}, 0), nil)
+
+			done := make(chan map[string]auctionrunner.Zone)
+			go func() {
+				done <- auctionrunner.FetchStateAndBuildZonesWithRetry(logger, clock, policy, auctionrunner.PullCellStateSource{}, nil, workPool, clients, metricEmitter, binPackFirstFitWeight)
+			}()
+
+			Eventually(repA.StateCallCount).Should(Equal(1))
+			Consistently(done).ShouldNot(Receive())
+
+			clock.WaitForWatcherAndIncrement(time.Second)
+
+			var zones map[string]auctionrunner.Zone
+			Eventually(done).Should(Receive(&zones))
+			Expect(zones["the-zone"]).To(HaveLen(1))
+			Expect(repA.StateCallCount()).To(Equal(2))
+		})
+	})
+
+	Context("with a cell state cache", func() {
+		var (
+			clock *fakeclock.FakeClock
+			cache *auctionrunner.CellStateCache
+		)
+
+		fetch := func() map[string]auctionrunner.Zone {
+			return auctionrunner.FetchStateAndBuildZonesWithRetry(logger, clock, auctionrunner.RetryPolicy{}, auctionrunner.PullCellStateSource{}, cache, workPool, clients, metricEmitter, binPackFirstFitWeight)
+		}
+
+		BeforeEach(func() {
+			clock = fakeclock.NewFakeClock(time.Now())
+			cache = auctionrunner.NewCellStateCache(clock, time.Minute)
+			fetch()
+		})
+
+		It("reuses cached states younger than the maximum age", func() {
+			zones := fetch()
+			Expect(zones["the-zone"]).To(HaveLen(2))
+			Expect(zones["other-zone"]).To(HaveLen(1))
+			Expect(repA.StateCallCount()).To(Equal(1))
+
+			Expect(metricEmitter.CellStateCacheHitsCallCount()).To(Equal(2))
+			hits, misses := metricEmitter.CellStateCacheHitsArgsForCall(0)
+			Expect(hits).To(Equal(0))
+			Expect(misses).To(Equal(3))
+			hits, misses = metricEmitter.CellStateCacheHitsArgsForCall(1)
+			Expect(hits).To(Equal(3))
+			Expect(misses).To(Equal(0))
+		})
+
+		It("fetches stale states again", func() {
+			clock.Increment(time.Minute)
+			fetch()
+			Expect(repA.StateCallCount()).To(Equal(2))
+		})
+
+		It("fetches invalidated states again", func() {
+			cache.Invalidate("A")
+			fetch()
+			Expect(repA.StateCallCount()).To(Equal(2))
+			Expect(repB.StateCallCount()).To(Equal(1))
+		})
+
+		It("fetches the states of cells sent work again", func() {
+			cache.InvalidatePlacements(auctiontypes.AuctionResults{
+				SuccessfulTasks: []auctiontypes.TaskAuction{{AuctionRecord: auctiontypes.AuctionRecord{Winner: "B"}}},
+			})
+			fetch()
+			Expect(repA.StateCallCount()).To(Equal(1))
+			Expect(repB.StateCallCount()).To(Equal(2))
+		})
+
+		It("does not let work reserved on one round's cells leak into the cache", func() {
+			zones := fetch()
+			for _, cell := range zones["other-zone"] {
+				Expect(cell.ReserveLRP(BuildLRP("pg-1", "domain", 0, linuxRootFSURL, 10, 10, 10, []string{}))).To(Succeed())
+			}
+
+			zones = fetch()
+			Expect(zones["other-zone"][0].State().LRPs).To(BeEmpty())
+		})
+
+		Context("when fetching a stale state fails", func() {
+			BeforeEach(func() {
+				repA.StateReturns(rep.CellState{}, errors.New("boom"))
+				clock.Increment(time.Minute)
+			})
+
+			It("leaves the cell out", func() {
+				zones := fetch()
+				Expect(zones["the-zone"]).To(HaveLen(1))
+				Expect(repA.StateCallCount()).To(Equal(2))
+			})
+		})
+	})
+
 	Context("when clients are slow to respond", func() {
 		BeforeEach(func() {
 			repA.StateReturns(BuildCellState("A", 0, "the-zone", 10, 10, 100, false, 0, linuxOnlyRootFSProviders, nil, []string{}, []string{}, []string{}, 0), errors.New("timeout"))
@@ -3,12 +3,38 @@ package auctionrunner
 import (
 	"sort"
 
-	"code.cloudfoundry.org/auction/auctiontypes"
+	"code.cloudfoundry.org/rep"
 )
 
 type lrpByZone struct {
 	zone      Zone
 	instances int
+	// domainInstances, when set, orders the zone in place of instances.  It
+	// holds the instances in each failure domain holding the zone's cells,
+	// outermost first.
+	domainInstances []int
+}
+
+func (z lrpByZone) spreadKey() []int {
+	if z.domainInstances != nil {
+		return z.domainInstances
+	}
+	return []int{z.instances}
+}
+
+// sameSpread reports whether the zones are equally good for spreading, so
+// that the best scoring cell across them should win.
+func (z lrpByZone) sameSpread(other lrpByZone) bool {
+	return compareSpreadKeys(z.spreadKey(), other.spreadKey()) == 0
+}
+
+func compareSpreadKeys(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+	return len(a) - len(b)
 }
 
 type zoneSorterByInstances struct {
@@ -18,22 +44,51 @@ type zoneSorterByInstances struct {
 func (s zoneSorterByInstances) Len() int      { return len(s.zones) }
 func (s zoneSorterByInstances) Swap(i, j int) { s.zones[i], s.zones[j] = s.zones[j], s.zones[i] }
 func (s zoneSorterByInstances) Less(i, j int) bool {
-	return s.zones[i].instances < s.zones[j].instances
+	return compareSpreadKeys(s.zones[i].spreadKey(), s.zones[j].spreadKey()) < 0
 }
 
 func accumulateZonesByInstances(zones map[string]Zone, processGuid string) []lrpByZone {
+	return accumulateZones(zones, func(cell *Cell) int {
+		return instancesOf(cell, processGuid)
+	})
+}
+
+func instancesOf(cell *Cell, processGuid string) int {
+	instances := 0
+	for i := range cell.state.LRPs {
+		if cell.state.LRPs[i].ProcessGuid == processGuid {
+			instances++
+		}
+	}
+	return instances
+}
+
+// accumulateZonesByTasks counts the tasks in each zone that are in the given
+// group.  Every zone counts as empty for the empty group.
+func accumulateZonesByTasks(zones map[string]Zone, grouping TaskGrouping, group string) []lrpByZone {
+	return accumulateZones(zones, func(cell *Cell) int {
+		if group == "" {
+			return 0
+		}
+		tasks := 0
+		for i := range cell.state.Tasks {
+			if grouping.TaskGroup(&cell.state.Tasks[i]) == group {
+				tasks++
+			}
+		}
+		return tasks
+	})
+}
+
+func accumulateZones(zones map[string]Zone, count func(*Cell) int) []lrpByZone {
 	lrpZones := []lrpByZone{}
 
 	for _, zone := range zones {
 		instances := 0
 		for _, cell := range zone {
-			for i := range cell.state.LRPs {
-				if cell.state.LRPs[i].ProcessGuid == processGuid {
-					instances++
-				}
-			}
+			instances += count(cell)
 		}
-		lrpZones = append(lrpZones, lrpByZone{zone, instances})
+		lrpZones = append(lrpZones, lrpByZone{zone: zone, instances: instances})
 	}
 
 	return lrpZones
@@ -45,21 +100,26 @@ func sortZonesByInstances(zones []lrpByZone) []lrpByZone {
 	return sorter.zones
 }
 
-func filterZones(zones []lrpByZone, lrpAuction *auctiontypes.LRPAuction) ([]lrpByZone, error) {
+func filterZones(zones []lrpByZone, filters placementFilters, lrp *rep.LRP) ([]lrpByZone, []CellRejection) {
+	return filterZonesWith(zones, func(zone Zone) ([]*Cell, []CellRejection) {
+		return filters.filterLRPCells(zone, lrp)
+	})
+}
+
+func filterTaskZones(zones []lrpByZone, filters placementFilters, task *rep.Task) ([]lrpByZone, []CellRejection) {
+	return filterZonesWith(zones, func(zone Zone) ([]*Cell, []CellRejection) {
+		return filters.filterTaskCells(zone, task)
+	})
+}
+
+func filterZonesWith(zones []lrpByZone, filterCells func(Zone) ([]*Cell, []CellRejection)) ([]lrpByZone, []CellRejection) {
 	filteredZones := []lrpByZone{}
-	var zoneError error
+	rejections := []CellRejection{}
 
 	for _, lrpZone := range zones {
-		cells, err := lrpZone.zone.filterCells(lrpAuction.PlacementConstraint)
-		if err != nil {
-			_, isZoneErrorPlacementTagMismatchError := zoneError.(auctiontypes.PlacementTagMismatchError)
-			_, isErrPlacementTagMismatchError := err.(auctiontypes.PlacementTagMismatchError)
-
-			if isZoneErrorPlacementTagMismatchError ||
-				(zoneError == auctiontypes.ErrorVolumeDriverMismatch && isErrPlacementTagMismatchError) ||
-				zoneError == auctiontypes.ErrorCellMismatch || zoneError == nil {
-				zoneError = err
-			}
+		cells, zoneRejections := filterCells(lrpZone.zone)
+		rejections = append(rejections, zoneRejections...)
+		if len(cells) == 0 {
 			continue
 		}
 
@@ -70,9 +130,5 @@ func filterZones(zones []lrpByZone, lrpAuction *auctiontypes.LRPAuction) ([]lrpB
 		filteredZones = append(filteredZones, filteredZone)
 	}
 
-	if len(filteredZones) == 0 {
-		return nil, zoneError
-	}
-
-	return filteredZones, nil
+	return filteredZones, rejections
 }
@@ -10,6 +10,44 @@ import (
 )
 
 type FakeAuctionRunner struct {
+	CancelLRPAuctionStub        func(string, int) error
+	cancelLRPAuctionMutex       sync.RWMutex
+	cancelLRPAuctionArgsForCall []struct {
+		arg1 string
+		arg2 int
+	}
+	cancelLRPAuctionReturns struct {
+		result1 error
+	}
+	cancelLRPAuctionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	CancelTaskAuctionStub        func(string) error
+	cancelTaskAuctionMutex       sync.RWMutex
+	cancelTaskAuctionArgsForCall []struct {
+		arg1 string
+	}
+	cancelTaskAuctionReturns struct {
+		result1 error
+	}
+	cancelTaskAuctionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	PlanAuctionsStub        func([]auctioneer.LRPStartRequest, []auctioneer.TaskStartRequest, string) (auctiontypes.AuctionResults, error)
+	planAuctionsMutex       sync.RWMutex
+	planAuctionsArgsForCall []struct {
+		arg1 []auctioneer.LRPStartRequest
+		arg2 []auctioneer.TaskStartRequest
+		arg3 string
+	}
+	planAuctionsReturns struct {
+		result1 auctiontypes.AuctionResults
+		result2 error
+	}
+	planAuctionsReturnsOnCall map[int]struct {
+		result1 auctiontypes.AuctionResults
+		result2 error
+	}
 	RunStub        func(<-chan os.Signal, chan<- struct{}) error
 	runMutex       sync.RWMutex
 	runArgsForCall []struct {
@@ -22,22 +60,248 @@ type FakeAuctionRunner struct {
 	runReturnsOnCall map[int]struct {
 		result1 error
 	}
-	ScheduleLRPsForAuctionsStub        func([]auctioneer.LRPStartRequest, string)
+	ScheduleLRPsForAuctionsStub        func([]auctioneer.LRPStartRequest, string) error
 	scheduleLRPsForAuctionsMutex       sync.RWMutex
 	scheduleLRPsForAuctionsArgsForCall []struct {
 		arg1 []auctioneer.LRPStartRequest
 		arg2 string
 	}
-	ScheduleTasksForAuctionsStub        func([]auctioneer.TaskStartRequest, string)
+	scheduleLRPsForAuctionsReturns struct {
+		result1 error
+	}
+	scheduleLRPsForAuctionsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ScheduleTasksForAuctionsStub        func([]auctioneer.TaskStartRequest, string) error
 	scheduleTasksForAuctionsMutex       sync.RWMutex
 	scheduleTasksForAuctionsArgsForCall []struct {
 		arg1 []auctioneer.TaskStartRequest
 		arg2 string
 	}
+	scheduleTasksForAuctionsReturns struct {
+		result1 error
+	}
+	scheduleTasksForAuctionsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StopLRPInstancesStub        func(string, int, string) (auctiontypes.LRPStopResults, error)
+	stopLRPInstancesMutex       sync.RWMutex
+	stopLRPInstancesArgsForCall []struct {
+		arg1 string
+		arg2 int
+		arg3 string
+	}
+	stopLRPInstancesReturns struct {
+		result1 auctiontypes.LRPStopResults
+		result2 error
+	}
+	stopLRPInstancesReturnsOnCall map[int]struct {
+		result1 auctiontypes.LRPStopResults
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeAuctionRunner) CancelLRPAuction(arg1 string, arg2 int) error {
+	fake.cancelLRPAuctionMutex.Lock()
+	ret, specificReturn := fake.cancelLRPAuctionReturnsOnCall[len(fake.cancelLRPAuctionArgsForCall)]
+	fake.cancelLRPAuctionArgsForCall = append(fake.cancelLRPAuctionArgsForCall, struct {
+		arg1 string
+		arg2 int
+	}{arg1, arg2})
+	stub := fake.CancelLRPAuctionStub
+	fakeReturns := fake.cancelLRPAuctionReturns
+	fake.recordInvocation("CancelLRPAuction", []interface{}{arg1, arg2})
+	fake.cancelLRPAuctionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeAuctionRunner) CancelLRPAuctionCallCount() int {
+	fake.cancelLRPAuctionMutex.RLock()
+	defer fake.cancelLRPAuctionMutex.RUnlock()
+	return len(fake.cancelLRPAuctionArgsForCall)
+}
+
+func (fake *FakeAuctionRunner) CancelLRPAuctionCalls(stub func(string, int) error) {
+	fake.cancelLRPAuctionMutex.Lock()
+	defer fake.cancelLRPAuctionMutex.Unlock()
+	fake.CancelLRPAuctionStub = stub
+}
+
+func (fake *FakeAuctionRunner) CancelLRPAuctionArgsForCall(i int) (string, int) {
+	fake.cancelLRPAuctionMutex.RLock()
+	defer fake.cancelLRPAuctionMutex.RUnlock()
+	argsForCall := fake.cancelLRPAuctionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAuctionRunner) CancelLRPAuctionReturns(result1 error) {
+	fake.cancelLRPAuctionMutex.Lock()
+	defer fake.cancelLRPAuctionMutex.Unlock()
+	fake.CancelLRPAuctionStub = nil
+	fake.cancelLRPAuctionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAuctionRunner) CancelLRPAuctionReturnsOnCall(i int, result1 error) {
+	fake.cancelLRPAuctionMutex.Lock()
+	defer fake.cancelLRPAuctionMutex.Unlock()
+	fake.CancelLRPAuctionStub = nil
+	if fake.cancelLRPAuctionReturnsOnCall == nil {
+		fake.cancelLRPAuctionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.cancelLRPAuctionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAuctionRunner) CancelTaskAuction(arg1 string) error {
+	fake.cancelTaskAuctionMutex.Lock()
+	ret, specificReturn := fake.cancelTaskAuctionReturnsOnCall[len(fake.cancelTaskAuctionArgsForCall)]
+	fake.cancelTaskAuctionArgsForCall = append(fake.cancelTaskAuctionArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.CancelTaskAuctionStub
+	fakeReturns := fake.cancelTaskAuctionReturns
+	fake.recordInvocation("CancelTaskAuction", []interface{}{arg1})
+	fake.cancelTaskAuctionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeAuctionRunner) CancelTaskAuctionCallCount() int {
+	fake.cancelTaskAuctionMutex.RLock()
+	defer fake.cancelTaskAuctionMutex.RUnlock()
+	return len(fake.cancelTaskAuctionArgsForCall)
+}
+
+func (fake *FakeAuctionRunner) CancelTaskAuctionCalls(stub func(string) error) {
+	fake.cancelTaskAuctionMutex.Lock()
+	defer fake.cancelTaskAuctionMutex.Unlock()
+	fake.CancelTaskAuctionStub = stub
+}
+
+func (fake *FakeAuctionRunner) CancelTaskAuctionArgsForCall(i int) string {
+	fake.cancelTaskAuctionMutex.RLock()
+	defer fake.cancelTaskAuctionMutex.RUnlock()
+	argsForCall := fake.cancelTaskAuctionArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAuctionRunner) CancelTaskAuctionReturns(result1 error) {
+	fake.cancelTaskAuctionMutex.Lock()
+	defer fake.cancelTaskAuctionMutex.Unlock()
+	fake.CancelTaskAuctionStub = nil
+	fake.cancelTaskAuctionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAuctionRunner) CancelTaskAuctionReturnsOnCall(i int, result1 error) {
+	fake.cancelTaskAuctionMutex.Lock()
+	defer fake.cancelTaskAuctionMutex.Unlock()
+	fake.CancelTaskAuctionStub = nil
+	if fake.cancelTaskAuctionReturnsOnCall == nil {
+		fake.cancelTaskAuctionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.cancelTaskAuctionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAuctionRunner) PlanAuctions(arg1 []auctioneer.LRPStartRequest, arg2 []auctioneer.TaskStartRequest, arg3 string) (auctiontypes.AuctionResults, error) {
+	var arg1Copy []auctioneer.LRPStartRequest
+	if arg1 != nil {
+		arg1Copy = make([]auctioneer.LRPStartRequest, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	var arg2Copy []auctioneer.TaskStartRequest
+	if arg2 != nil {
+		arg2Copy = make([]auctioneer.TaskStartRequest, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.planAuctionsMutex.Lock()
+	ret, specificReturn := fake.planAuctionsReturnsOnCall[len(fake.planAuctionsArgsForCall)]
+	fake.planAuctionsArgsForCall = append(fake.planAuctionsArgsForCall, struct {
+		arg1 []auctioneer.LRPStartRequest
+		arg2 []auctioneer.TaskStartRequest
+		arg3 string
+	}{arg1Copy, arg2Copy, arg3})
+	stub := fake.PlanAuctionsStub
+	fakeReturns := fake.planAuctionsReturns
+	fake.recordInvocation("PlanAuctions", []interface{}{arg1Copy, arg2Copy, arg3})
+	fake.planAuctionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAuctionRunner) PlanAuctionsCallCount() int {
+	fake.planAuctionsMutex.RLock()
+	defer fake.planAuctionsMutex.RUnlock()
+	return len(fake.planAuctionsArgsForCall)
+}
+
+func (fake *FakeAuctionRunner) PlanAuctionsCalls(stub func([]auctioneer.LRPStartRequest, []auctioneer.TaskStartRequest, string) (auctiontypes.AuctionResults, error)) {
+	fake.planAuctionsMutex.Lock()
+	defer fake.planAuctionsMutex.Unlock()
+	fake.PlanAuctionsStub = stub
+}
+
+func (fake *FakeAuctionRunner) PlanAuctionsArgsForCall(i int) ([]auctioneer.LRPStartRequest, []auctioneer.TaskStartRequest, string) {
+	fake.planAuctionsMutex.RLock()
+	defer fake.planAuctionsMutex.RUnlock()
+	argsForCall := fake.planAuctionsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeAuctionRunner) PlanAuctionsReturns(result1 auctiontypes.AuctionResults, result2 error) {
+	fake.planAuctionsMutex.Lock()
+	defer fake.planAuctionsMutex.Unlock()
+	fake.PlanAuctionsStub = nil
+	fake.planAuctionsReturns = struct {
+		result1 auctiontypes.AuctionResults
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAuctionRunner) PlanAuctionsReturnsOnCall(i int, result1 auctiontypes.AuctionResults, result2 error) {
+	fake.planAuctionsMutex.Lock()
+	defer fake.planAuctionsMutex.Unlock()
+	fake.PlanAuctionsStub = nil
+	if fake.planAuctionsReturnsOnCall == nil {
+		fake.planAuctionsReturnsOnCall = make(map[int]struct {
+			result1 auctiontypes.AuctionResults
+			result2 error
+		})
+	}
+	fake.planAuctionsReturnsOnCall[i] = struct {
+		result1 auctiontypes.AuctionResults
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAuctionRunner) Run(arg1 <-chan os.Signal, arg2 chan<- struct{}) error {
 	fake.runMutex.Lock()
 	ret, specificReturn := fake.runReturnsOnCall[len(fake.runArgsForCall)]
@@ -100,23 +364,29 @@ func (fake *FakeAuctionRunner) RunReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeAuctionRunner) ScheduleLRPsForAuctions(arg1 []auctioneer.LRPStartRequest, arg2 string) {
+func (fake *FakeAuctionRunner) ScheduleLRPsForAuctions(arg1 []auctioneer.LRPStartRequest, arg2 string) error {
 	var arg1Copy []auctioneer.LRPStartRequest
 	if arg1 != nil {
 		arg1Copy = make([]auctioneer.LRPStartRequest, len(arg1))
 		copy(arg1Copy, arg1)
 	}
 	fake.scheduleLRPsForAuctionsMutex.Lock()
+	ret, specificReturn := fake.scheduleLRPsForAuctionsReturnsOnCall[len(fake.scheduleLRPsForAuctionsArgsForCall)]
 	fake.scheduleLRPsForAuctionsArgsForCall = append(fake.scheduleLRPsForAuctionsArgsForCall, struct {
 		arg1 []auctioneer.LRPStartRequest
 		arg2 string
 	}{arg1Copy, arg2})
 	stub := fake.ScheduleLRPsForAuctionsStub
+	fakeReturns := fake.scheduleLRPsForAuctionsReturns
 	fake.recordInvocation("ScheduleLRPsForAuctions", []interface{}{arg1Copy, arg2})
 	fake.scheduleLRPsForAuctionsMutex.Unlock()
 	if stub != nil {
-		fake.ScheduleLRPsForAuctionsStub(arg1, arg2)
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
 	}
+	return fakeReturns.result1
 }
 
 func (fake *FakeAuctionRunner) ScheduleLRPsForAuctionsCallCount() int {
@@ -125,7 +395,7 @@ func (fake *FakeAuctionRunner) ScheduleLRPsForAuctionsCallCount() int {
 	return len(fake.scheduleLRPsForAuctionsArgsForCall)
 }
 
-func (fake *FakeAuctionRunner) ScheduleLRPsForAuctionsCalls(stub func([]auctioneer.LRPStartRequest, string)) {
+func (fake *FakeAuctionRunner) ScheduleLRPsForAuctionsCalls(stub func([]auctioneer.LRPStartRequest, string) error) {
 	fake.scheduleLRPsForAuctionsMutex.Lock()
 	defer fake.scheduleLRPsForAuctionsMutex.Unlock()
 	fake.ScheduleLRPsForAuctionsStub = stub
@@ -138,23 +408,52 @@ func (fake *FakeAuctionRunner) ScheduleLRPsForAuctionsArgsForCall(i int) ([]auct
 	return argsForCall.arg1, argsForCall.arg2
 }
 
-func (fake *FakeAuctionRunner) ScheduleTasksForAuctions(arg1 []auctioneer.TaskStartRequest, arg2 string) {
+func (fake *FakeAuctionRunner) ScheduleLRPsForAuctionsReturns(result1 error) {
+	fake.scheduleLRPsForAuctionsMutex.Lock()
+	defer fake.scheduleLRPsForAuctionsMutex.Unlock()
+	fake.ScheduleLRPsForAuctionsStub = nil
+	fake.scheduleLRPsForAuctionsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAuctionRunner) ScheduleLRPsForAuctionsReturnsOnCall(i int, result1 error) {
+	fake.scheduleLRPsForAuctionsMutex.Lock()
+	defer fake.scheduleLRPsForAuctionsMutex.Unlock()
+	fake.ScheduleLRPsForAuctionsStub = nil
+	if fake.scheduleLRPsForAuctionsReturnsOnCall == nil {
+		fake.scheduleLRPsForAuctionsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.scheduleLRPsForAuctionsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAuctionRunner) ScheduleTasksForAuctions(arg1 []auctioneer.TaskStartRequest, arg2 string) error {
 	var arg1Copy []auctioneer.TaskStartRequest
 	if arg1 != nil {
 		arg1Copy = make([]auctioneer.TaskStartRequest, len(arg1))
 		copy(arg1Copy, arg1)
 	}
 	fake.scheduleTasksForAuctionsMutex.Lock()
+	ret, specificReturn := fake.scheduleTasksForAuctionsReturnsOnCall[len(fake.scheduleTasksForAuctionsArgsForCall)]
 	fake.scheduleTasksForAuctionsArgsForCall = append(fake.scheduleTasksForAuctionsArgsForCall, struct {
 		arg1 []auctioneer.TaskStartRequest
 		arg2 string
 	}{arg1Copy, arg2})
 	stub := fake.ScheduleTasksForAuctionsStub
+	fakeReturns := fake.scheduleTasksForAuctionsReturns
 	fake.recordInvocation("ScheduleTasksForAuctions", []interface{}{arg1Copy, arg2})
 	fake.scheduleTasksForAuctionsMutex.Unlock()
 	if stub != nil {
-		fake.ScheduleTasksForAuctionsStub(arg1, arg2)
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
 	}
+	return fakeReturns.result1
 }
 
 func (fake *FakeAuctionRunner) ScheduleTasksForAuctionsCallCount() int {
@@ -163,7 +462,7 @@ func (fake *FakeAuctionRunner) ScheduleTasksForAuctionsCallCount() int {
 	return len(fake.scheduleTasksForAuctionsArgsForCall)
 }
 
-func (fake *FakeAuctionRunner) ScheduleTasksForAuctionsCalls(stub func([]auctioneer.TaskStartRequest, string)) {
+func (fake *FakeAuctionRunner) ScheduleTasksForAuctionsCalls(stub func([]auctioneer.TaskStartRequest, string) error) {
 	fake.scheduleTasksForAuctionsMutex.Lock()
 	defer fake.scheduleTasksForAuctionsMutex.Unlock()
 	fake.ScheduleTasksForAuctionsStub = stub
@@ -176,15 +475,112 @@ func (fake *FakeAuctionRunner) ScheduleTasksForAuctionsArgsForCall(i int) ([]auc
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeAuctionRunner) ScheduleTasksForAuctionsReturns(result1 error) {
+	fake.scheduleTasksForAuctionsMutex.Lock()
+	defer fake.scheduleTasksForAuctionsMutex.Unlock()
+	fake.ScheduleTasksForAuctionsStub = nil
+	fake.scheduleTasksForAuctionsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAuctionRunner) ScheduleTasksForAuctionsReturnsOnCall(i int, result1 error) {
+	fake.scheduleTasksForAuctionsMutex.Lock()
+	defer fake.scheduleTasksForAuctionsMutex.Unlock()
+	fake.ScheduleTasksForAuctionsStub = nil
+	if fake.scheduleTasksForAuctionsReturnsOnCall == nil {
+		fake.scheduleTasksForAuctionsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.scheduleTasksForAuctionsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAuctionRunner) StopLRPInstances(arg1 string, arg2 int, arg3 string) (auctiontypes.LRPStopResults, error) {
+	fake.stopLRPInstancesMutex.Lock()
+	ret, specificReturn := fake.stopLRPInstancesReturnsOnCall[len(fake.stopLRPInstancesArgsForCall)]
+	fake.stopLRPInstancesArgsForCall = append(fake.stopLRPInstancesArgsForCall, struct {
+		arg1 string
+		arg2 int
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.StopLRPInstancesStub
+	fakeReturns := fake.stopLRPInstancesReturns
+	fake.recordInvocation("StopLRPInstances", []interface{}{arg1, arg2, arg3})
+	fake.stopLRPInstancesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAuctionRunner) StopLRPInstancesCallCount() int {
+	fake.stopLRPInstancesMutex.RLock()
+	defer fake.stopLRPInstancesMutex.RUnlock()
+	return len(fake.stopLRPInstancesArgsForCall)
+}
+
+func (fake *FakeAuctionRunner) StopLRPInstancesCalls(stub func(string, int, string) (auctiontypes.LRPStopResults, error)) {
+	fake.stopLRPInstancesMutex.Lock()
+	defer fake.stopLRPInstancesMutex.Unlock()
+	fake.StopLRPInstancesStub = stub
+}
+
+func (fake *FakeAuctionRunner) StopLRPInstancesArgsForCall(i int) (string, int, string) {
+	fake.stopLRPInstancesMutex.RLock()
+	defer fake.stopLRPInstancesMutex.RUnlock()
+	argsForCall := fake.stopLRPInstancesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeAuctionRunner) StopLRPInstancesReturns(result1 auctiontypes.LRPStopResults, result2 error) {
+	fake.stopLRPInstancesMutex.Lock()
+	defer fake.stopLRPInstancesMutex.Unlock()
+	fake.StopLRPInstancesStub = nil
+	fake.stopLRPInstancesReturns = struct {
+		result1 auctiontypes.LRPStopResults
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAuctionRunner) StopLRPInstancesReturnsOnCall(i int, result1 auctiontypes.LRPStopResults, result2 error) {
+	fake.stopLRPInstancesMutex.Lock()
+	defer fake.stopLRPInstancesMutex.Unlock()
+	fake.StopLRPInstancesStub = nil
+	if fake.stopLRPInstancesReturnsOnCall == nil {
+		fake.stopLRPInstancesReturnsOnCall = make(map[int]struct {
+			result1 auctiontypes.LRPStopResults
+			result2 error
+		})
+	}
+	fake.stopLRPInstancesReturnsOnCall[i] = struct {
+		result1 auctiontypes.LRPStopResults
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAuctionRunner) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.cancelLRPAuctionMutex.RLock()
+	defer fake.cancelLRPAuctionMutex.RUnlock()
+	fake.cancelTaskAuctionMutex.RLock()
+	defer fake.cancelTaskAuctionMutex.RUnlock()
+	fake.planAuctionsMutex.RLock()
+	defer fake.planAuctionsMutex.RUnlock()
 	fake.runMutex.RLock()
 	defer fake.runMutex.RUnlock()
 	fake.scheduleLRPsForAuctionsMutex.RLock()
 	defer fake.scheduleLRPsForAuctionsMutex.RUnlock()
 	fake.scheduleTasksForAuctionsMutex.RLock()
 	defer fake.scheduleTasksForAuctionsMutex.RUnlock()
+	fake.stopLRPInstancesMutex.RLock()
+	defer fake.stopLRPInstancesMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -20,6 +20,42 @@ type FakeAuctionMetricEmitterDelegate struct {
 	auctionCompletedReturnsOnCall map[int]struct {
 		result1 error
 	}
+	BatchDepthStub        func(int, int) error
+	batchDepthMutex       sync.RWMutex
+	batchDepthArgsForCall []struct {
+		arg1 int
+		arg2 int
+	}
+	batchDepthReturns struct {
+		result1 error
+	}
+	batchDepthReturnsOnCall map[int]struct {
+		result1 error
+	}
+	BatchRejectedStub        func(int, int) error
+	batchRejectedMutex       sync.RWMutex
+	batchRejectedArgsForCall []struct {
+		arg1 int
+		arg2 int
+	}
+	batchRejectedReturns struct {
+		result1 error
+	}
+	batchRejectedReturnsOnCall map[int]struct {
+		result1 error
+	}
+	CellStateCacheHitsStub        func(int, int) error
+	cellStateCacheHitsMutex       sync.RWMutex
+	cellStateCacheHitsArgsForCall []struct {
+		arg1 int
+		arg2 int
+	}
+	cellStateCacheHitsReturns struct {
+		result1 error
+	}
+	cellStateCacheHitsReturnsOnCall map[int]struct {
+		result1 error
+	}
 	FailedCellStateRequestStub        func() error
 	failedCellStateRequestMutex       sync.RWMutex
 	failedCellStateRequestArgsForCall []struct {
@@ -106,6 +142,192 @@ func (fake *FakeAuctionMetricEmitterDelegate) AuctionCompletedReturnsOnCall(i in
 	}{result1}
 }
 
+func (fake *FakeAuctionMetricEmitterDelegate) BatchDepth(arg1 int, arg2 int) error {
+	fake.batchDepthMutex.Lock()
+	ret, specificReturn := fake.batchDepthReturnsOnCall[len(fake.batchDepthArgsForCall)]
+	fake.batchDepthArgsForCall = append(fake.batchDepthArgsForCall, struct {
+		arg1 int
+		arg2 int
+	}{arg1, arg2})
+	stub := fake.BatchDepthStub
+	fakeReturns := fake.batchDepthReturns
+	fake.recordInvocation("BatchDepth", []interface{}{arg1, arg2})
+	fake.batchDepthMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) BatchDepthCallCount() int {
+	fake.batchDepthMutex.RLock()
+	defer fake.batchDepthMutex.RUnlock()
+	return len(fake.batchDepthArgsForCall)
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) BatchDepthCalls(stub func(int, int) error) {
+	fake.batchDepthMutex.Lock()
+	defer fake.batchDepthMutex.Unlock()
+	fake.BatchDepthStub = stub
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) BatchDepthArgsForCall(i int) (int, int) {
+	fake.batchDepthMutex.RLock()
+	defer fake.batchDepthMutex.RUnlock()
+	argsForCall := fake.batchDepthArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) BatchDepthReturns(result1 error) {
+	fake.batchDepthMutex.Lock()
+	defer fake.batchDepthMutex.Unlock()
+	fake.BatchDepthStub = nil
+	fake.batchDepthReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) BatchDepthReturnsOnCall(i int, result1 error) {
+	fake.batchDepthMutex.Lock()
+	defer fake.batchDepthMutex.Unlock()
+	fake.BatchDepthStub = nil
+	if fake.batchDepthReturnsOnCall == nil {
+		fake.batchDepthReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.batchDepthReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) BatchRejected(arg1 int, arg2 int) error {
+	fake.batchRejectedMutex.Lock()
+	ret, specificReturn := fake.batchRejectedReturnsOnCall[len(fake.batchRejectedArgsForCall)]
+	fake.batchRejectedArgsForCall = append(fake.batchRejectedArgsForCall, struct {
+		arg1 int
+		arg2 int
+	}{arg1, arg2})
+	stub := fake.BatchRejectedStub
+	fakeReturns := fake.batchRejectedReturns
+	fake.recordInvocation("BatchRejected", []interface{}{arg1, arg2})
+	fake.batchRejectedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) BatchRejectedCallCount() int {
+	fake.batchRejectedMutex.RLock()
+	defer fake.batchRejectedMutex.RUnlock()
+	return len(fake.batchRejectedArgsForCall)
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) BatchRejectedCalls(stub func(int, int) error) {
+	fake.batchRejectedMutex.Lock()
+	defer fake.batchRejectedMutex.Unlock()
+	fake.BatchRejectedStub = stub
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) BatchRejectedArgsForCall(i int) (int, int) {
+	fake.batchRejectedMutex.RLock()
+	defer fake.batchRejectedMutex.RUnlock()
+	argsForCall := fake.batchRejectedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) BatchRejectedReturns(result1 error) {
+	fake.batchRejectedMutex.Lock()
+	defer fake.batchRejectedMutex.Unlock()
+	fake.BatchRejectedStub = nil
+	fake.batchRejectedReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) BatchRejectedReturnsOnCall(i int, result1 error) {
+	fake.batchRejectedMutex.Lock()
+	defer fake.batchRejectedMutex.Unlock()
+	fake.BatchRejectedStub = nil
+	if fake.batchRejectedReturnsOnCall == nil {
+		fake.batchRejectedReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.batchRejectedReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) CellStateCacheHits(arg1 int, arg2 int) error {
+	fake.cellStateCacheHitsMutex.Lock()
+	ret, specificReturn := fake.cellStateCacheHitsReturnsOnCall[len(fake.cellStateCacheHitsArgsForCall)]
+	fake.cellStateCacheHitsArgsForCall = append(fake.cellStateCacheHitsArgsForCall, struct {
+		arg1 int
+		arg2 int
+	}{arg1, arg2})
+	stub := fake.CellStateCacheHitsStub
+	fakeReturns := fake.cellStateCacheHitsReturns
+	fake.recordInvocation("CellStateCacheHits", []interface{}{arg1, arg2})
+	fake.cellStateCacheHitsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) CellStateCacheHitsCallCount() int {
+	fake.cellStateCacheHitsMutex.RLock()
+	defer fake.cellStateCacheHitsMutex.RUnlock()
+	return len(fake.cellStateCacheHitsArgsForCall)
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) CellStateCacheHitsCalls(stub func(int, int) error) {
+	fake.cellStateCacheHitsMutex.Lock()
+	defer fake.cellStateCacheHitsMutex.Unlock()
+	fake.CellStateCacheHitsStub = stub
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) CellStateCacheHitsArgsForCall(i int) (int, int) {
+	fake.cellStateCacheHitsMutex.RLock()
+	defer fake.cellStateCacheHitsMutex.RUnlock()
+	argsForCall := fake.cellStateCacheHitsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) CellStateCacheHitsReturns(result1 error) {
+	fake.cellStateCacheHitsMutex.Lock()
+	defer fake.cellStateCacheHitsMutex.Unlock()
+	fake.CellStateCacheHitsStub = nil
+	fake.cellStateCacheHitsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAuctionMetricEmitterDelegate) CellStateCacheHitsReturnsOnCall(i int, result1 error) {
+	fake.cellStateCacheHitsMutex.Lock()
+	defer fake.cellStateCacheHitsMutex.Unlock()
+	fake.CellStateCacheHitsStub = nil
+	if fake.cellStateCacheHitsReturnsOnCall == nil {
+		fake.cellStateCacheHitsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.cellStateCacheHitsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeAuctionMetricEmitterDelegate) FailedCellStateRequest() error {
 	fake.failedCellStateRequestMutex.Lock()
 	ret, specificReturn := fake.failedCellStateRequestReturnsOnCall[len(fake.failedCellStateRequestArgsForCall)]
@@ -225,6 +447,12 @@ func (fake *FakeAuctionMetricEmitterDelegate) Invocations() map[string][][]inter
 	defer fake.invocationsMutex.RUnlock()
 	fake.auctionCompletedMutex.RLock()
 	defer fake.auctionCompletedMutex.RUnlock()
+	fake.batchDepthMutex.RLock()
+	defer fake.batchDepthMutex.RUnlock()
+	fake.batchRejectedMutex.RLock()
+	defer fake.batchRejectedMutex.RUnlock()
+	fake.cellStateCacheHitsMutex.RLock()
+	defer fake.cellStateCacheHitsMutex.RUnlock()
 	fake.failedCellStateRequestMutex.RLock()
 	defer fake.failedCellStateRequestMutex.RUnlock()
 	fake.fetchStatesCompletedMutex.RLock()
@@ -2,6 +2,7 @@ package auctiontypes
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -41,6 +42,60 @@ func (e PlacementTagMismatchError) Error() string {
 	}
 }
 
+// SpreadConstraintError is returned when an LRP's spread constraint blocks
+// its placement.
+type SpreadConstraintError struct {
+	Constraint SpreadConstraint
+	Reason     string
+}
+
+func (e SpreadConstraintError) Error() string {
+	return fmt.Sprintf("spread constraint (%s) blocked placement: %s", e.Constraint, e.Reason)
+}
+
+// InstanceCapError is the reason a cell is rejected when another instance of
+// an LRP would go over its cap on the cell or in the cell's zone.
+type InstanceCapError struct {
+	// Scope is "cell" or "zone".
+	Scope string
+	Max   int
+}
+
+func (e InstanceCapError) Error() string {
+	return fmt.Sprintf("found no cell below the maximum of %d instances per %s", e.Max, e.Scope)
+}
+
+// AffinityRuleError is the reason a cell is rejected for breaking a hard
+// affinity rule.
+type AffinityRuleError struct {
+	Rule AffinityRule
+}
+
+func (e AffinityRuleError) Error() string {
+	return fmt.Sprintf("found no cell satisfying %s", e.Rule)
+}
+
+const (
+	LRPStartWork = "lrp-start"
+	TaskWork     = "task"
+)
+
+// BatchFullError is returned when submitted work does not fit in the batch.
+// None of the submitted work was added.
+type BatchFullError struct {
+	WorkType  string
+	Capacity  int
+	Pending   int
+	Submitted int
+}
+
+func (e BatchFullError) Error() string {
+	return fmt.Sprintf("auction batch full: cannot add %d %s auctions, %d of %d pending", e.Submitted, e.WorkType, e.Pending, e.Capacity)
+}
+
+var ErrorBatchClosed = errors.New("auction runner is shutting down: not accepting work")
+var ErrorAuctionNotFound = errors.New("no pending auction found")
+var ErrorAuctionInProgress = errors.New("auction already in progress: too late to cancel")
 var ErrorNothingToStop = errors.New("nothing to stop")
 var ErrorCellCommunication = errors.New("unable to communicate to compatible cells")
 var ErrorExceededInflightCreation = errors.New("waiting to start instance: reached in-flight start limit")
@@ -48,13 +103,19 @@ var ErrorExceededInflightCreation = errors.New("waiting to start instance: reach
 //go:generate counterfeiter -o fakes/fake_auction_runner.go . AuctionRunner
 type AuctionRunner interface {
 	ifrit.Runner
-	ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest, string)
-	ScheduleTasksForAuctions([]auctioneer.TaskStartRequest, string)
+	ScheduleLRPsForAuctions([]auctioneer.LRPStartRequest, string) error
+	ScheduleTasksForAuctions([]auctioneer.TaskStartRequest, string) error
+	PlanAuctions([]auctioneer.LRPStartRequest, []auctioneer.TaskStartRequest, string) (AuctionResults, error)
+	StopLRPInstances(processGuid string, count int, traceID string) (LRPStopResults, error)
+	CancelLRPAuction(processGuid string, index int) error
+	CancelTaskAuction(taskGuid string) error
 }
 
+//go:generate counterfeiter -o fakes/fake_auction_runner_delegate.go . AuctionRunnerDelegate
 type AuctionRunnerDelegate interface {
 	FetchCellReps(lager.Logger, string) (map[string]rep.Client, error)
-	AuctionCompleted(lager.Logger, string, AuctionResults)
+	AuctionCompleted(lager.Logger, []string, AuctionResults)
+	HandBackAuctions(lager.Logger, AuctionRequest)
 }
 
 //go:generate counterfeiter -o fakes/fake_metric_emitter.go . AuctionMetricEmitterDelegate
@@ -62,6 +123,9 @@ type AuctionMetricEmitterDelegate interface {
 	FetchStatesCompleted(time.Duration) error
 	FailedCellStateRequest() error
 	AuctionCompleted(AuctionResults) error
+	BatchDepth(lrpStarts, tasks int) error
+	BatchRejected(lrpStarts, tasks int) error
+	CellStateCacheHits(hits, misses int) error
 }
 
 type AuctionRequest struct {
@@ -69,11 +133,39 @@ type AuctionRequest struct {
 	Tasks []TaskAuction
 }
 
+// TraceIDs returns the distinct trace IDs of the requested auctions, in the
+// order they first appear.
+func (r AuctionRequest) TraceIDs() []string {
+	traceIDs := []string{}
+	seen := map[string]bool{}
+	add := func(traceID string) {
+		if traceID == "" || seen[traceID] {
+			return
+		}
+		seen[traceID] = true
+		traceIDs = append(traceIDs, traceID)
+	}
+
+	for i := range r.LRPs {
+		add(r.LRPs[i].TraceID)
+	}
+	for i := range r.Tasks {
+		add(r.Tasks[i].TraceID)
+	}
+	return traceIDs
+}
+
 type AuctionResults struct {
 	SuccessfulLRPs  []LRPAuction
 	SuccessfulTasks []TaskAuction
 	FailedLRPs      []LRPAuction
 	FailedTasks     []TaskAuction
+
+	// UnknownLRPs and UnknownTasks hold work sent to a cell that may or may
+	// not have been placed: the commit failed and the cell's state could not
+	// be read back to find out.
+	UnknownLRPs  []LRPAuction
+	UnknownTasks []TaskAuction
 }
 
 // LRPStart and Task Auctions
@@ -82,10 +174,63 @@ type AuctionRecord struct {
 	Winner   string
 	Attempts int
 
+	// TraceID is the trace the work was submitted under.
+	TraceID string
+
+	// Priority is the work's priority class.  Higher priorities are placed
+	// first; the default is 0.
+	Priority int
+	// AgingBoost is what the scheduler's aging policy added to Priority,
+	// for how often the work was tried and how long it waited, when it last
+	// ordered the work.
+	AgingBoost int
+
 	QueueTime    time.Time
 	WaitDuration time.Duration
 
 	PlacementError string
+
+	// Explanation is only filled in when the scheduler is asked to explain
+	// its placements.
+	Explanation *PlacementExplanation
+}
+
+// PlacementExplanation describes how every candidate cell fared when an
+// auction was placed.
+type PlacementExplanation struct {
+	Cells []CellExplanation
+}
+
+type CellExplanation struct {
+	CellID string
+	Zone   string
+
+	// Filter names the placement filter that rejected the cell, if any.
+	Filter string
+	// Reason says why the cell could not take the work. It is empty for
+	// cells that qualified.
+	Reason string
+	// MissingResources lists the resources the cell did not have enough of.
+	MissingResources []string
+
+	// Score is set for every cell that qualified and was scored.
+	Score  *ScoreBreakdown
+	Winner bool
+}
+
+// ScoreBreakdown splits a cell's score into its parts. Lower scores win.
+type ScoreBreakdown struct {
+	Resource float64
+	Locality float64
+	Index    float64
+	// Affinity is what weighted affinity rules add to the score.
+	Affinity float64
+	Total    float64
+}
+
+// EffectivePriority is the priority the work is ordered by.
+func (r AuctionRecord) EffectivePriority() int {
+	return r.Priority + r.AgingBoost
 }
 
 func NewAuctionRecord(now time.Time) AuctionRecord {
@@ -95,31 +240,137 @@ func NewAuctionRecord(now time.Time) AuctionRecord {
 type LRPAuction struct {
 	rep.LRP
 	AuctionRecord
+
+	Constraints LRPConstraints
 }
 
 func NewLRPAuction(lrp rep.LRP, now time.Time) LRPAuction {
 	return LRPAuction{
-		lrp,
-		NewAuctionRecord(now),
+		LRP:           lrp,
+		AuctionRecord: NewAuctionRecord(now),
 	}
 }
 
 func (a *LRPAuction) Copy() LRPAuction {
-	return LRPAuction{a.LRP.Copy(), a.AuctionRecord}
+	return LRPAuction{a.LRP.Copy(), a.AuctionRecord, a.Constraints}
 }
 
 type TaskAuction struct {
 	rep.Task
 	AuctionRecord
+
+	Constraints TaskConstraints
 }
 
 func NewTaskAuction(task rep.Task, now time.Time) TaskAuction {
 	return TaskAuction{
-		task,
-		NewAuctionRecord(now),
+		Task:          task,
+		AuctionRecord: NewAuctionRecord(now),
 	}
 }
 
 func (a *TaskAuction) Copy() TaskAuction {
-	return TaskAuction{a.Task.Copy(), a.AuctionRecord}
+	return TaskAuction{a.Task.Copy(), a.AuctionRecord, a.Constraints}
+}
+
+// LRPConstraints are placement rules for an LRP beyond those in its
+// rep.PlacementConstraint.
+type LRPConstraints struct {
+	Spread   *SpreadConstraint `json:",omitempty"`
+	Affinity []AffinityRule    `json:",omitempty"`
+
+	// MaxInstancesPerCell and MaxInstancesPerZone cap the instances of the
+	// LRP on a cell and in a zone.  Zero means no cap.
+	MaxInstancesPerCell int `json:",omitempty"`
+	MaxInstancesPerZone int `json:",omitempty"`
+}
+
+// TaskConstraints are placement rules for a task beyond those in its
+// rep.PlacementConstraint.
+type TaskConstraints struct {
+	Affinity []AffinityRule `json:",omitempty"`
+}
+
+// AffinityRule ties where work is placed to the cells running instances of
+// other LRPs.  A cell matches the rule if it runs an instance of any of
+// ProcessGuids.
+type AffinityRule struct {
+	ProcessGuids []string
+	// Anti keeps the work off matching cells, rather than on them.
+	Anti bool
+
+	// Hard makes the rule a requirement: cells that break it are not
+	// considered, and Weight is ignored.
+	Hard bool
+	// Weight makes a rule that is not hard a preference: each matching
+	// instance on a cell lowers its score by Weight, or raises it for an
+	// anti-affinity rule.
+	Weight float64
+}
+
+func (r AffinityRule) String() string {
+	kind := "affinity"
+	if r.Anti {
+		kind = "anti-affinity"
+	}
+	return kind + " with " + strings.Join(r.ProcessGuids, ", ")
+}
+
+type SpreadMode int
+
+const (
+	// SpreadSoft places an instance in a zone that keeps the skew within
+	// MaxSkew if one has room, and in any other zone if not.
+	SpreadSoft SpreadMode = iota
+	// SpreadHard only places an instance in a zone that keeps the skew
+	// within MaxSkew.
+	SpreadHard
+)
+
+func (m SpreadMode) String() string {
+	if m == SpreadHard {
+		return "hard"
+	}
+	return "soft"
+}
+
+// SpreadConstraint limits how unevenly an LRP's instances are spread across
+// the zones it can be placed in.  The skew of placing an instance in a zone
+// is the number of instances the zone would then have, less the number in
+// the zone with the fewest.  Zones within MaxSkew are treated alike, and the
+// best scoring cell among them wins.
+type SpreadConstraint struct {
+	// MaxSkew is at least 1; lower values are taken as 1.
+	MaxSkew int
+	Mode    SpreadMode
+
+	// DoNotSchedule refuses to place an instance anywhere that would leave
+	// the difference between the fullest and emptiest zones above MaxSkew,
+	// even in soft mode.  When the zones are already further apart, as when
+	// a zone comes back after an outage, an instance may still go anywhere
+	// that does not widen the difference, so the emptiest zones fill back
+	// up.
+	DoNotSchedule bool
+}
+
+func (c SpreadConstraint) String() string {
+	s := fmt.Sprintf("max skew %d, %s", c.MaxSkew, c.Mode)
+	if c.DoNotSchedule {
+		s += ", do not schedule"
+	}
+	return s
+}
+
+// LRP Stop Auctions
+
+type LRPStop struct {
+	rep.LRP
+	CellID string
+
+	StopError string
+}
+
+type LRPStopResults struct {
+	SuccessfulStops []LRPStop
+	FailedStops     []LRPStop
 }
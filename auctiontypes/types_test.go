@@ -72,4 +72,36 @@ var _ = Describe("Types", func() {
 			Expect(err.Error()).To(Equal("found no compatible cell for required rootfs"))
 		})
 	})
+
+	Describe("InstanceCapError", func() {
+		It("names the cap", func() {
+			err := auctiontypes.InstanceCapError{Scope: "zone", Max: 3}
+			Expect(err.Error()).To(Equal("found no cell below the maximum of 3 instances per zone"))
+		})
+	})
+
+	Describe("AffinityRuleError", func() {
+		It("names the rule", func() {
+			err := auctiontypes.AffinityRuleError{Rule: auctiontypes.AffinityRule{ProcessGuids: []string{"pg-1", "pg-2"}, Anti: true}}
+			Expect(err.Error()).To(Equal("found no cell satisfying anti-affinity with pg-1, pg-2"))
+		})
+	})
+
+	Describe("AuctionRequest.TraceIDs", func() {
+		It("lists each trace once, in the order they first appear", func() {
+			request := auctiontypes.AuctionRequest{
+				LRPs: []auctiontypes.LRPAuction{
+					{AuctionRecord: auctiontypes.AuctionRecord{TraceID: "trace-1"}},
+					{AuctionRecord: auctiontypes.AuctionRecord{TraceID: "trace-2"}},
+					{AuctionRecord: auctiontypes.AuctionRecord{TraceID: "trace-1"}},
+				},
+				Tasks: []auctiontypes.TaskAuction{
+					{AuctionRecord: auctiontypes.AuctionRecord{TraceID: ""}},
+					{AuctionRecord: auctiontypes.AuctionRecord{TraceID: "trace-3"}},
+				},
+			}
+
+			Expect(request.TraceIDs()).To(Equal([]string{"trace-1", "trace-2", "trace-3"}))
+		})
+	})
 })
@@ -19,3 +19,9 @@ func (auctionMetricEmitterDelegate) FetchStatesCompleted(_ time.Duration) error
 func (auctionMetricEmitterDelegate) FailedCellStateRequest() error { return nil }
 
 func (auctionMetricEmitterDelegate) AuctionCompleted(_ auctiontypes.AuctionResults) error { return nil }
+
+func (auctionMetricEmitterDelegate) BatchDepth(_, _ int) error { return nil }
+
+func (auctionMetricEmitterDelegate) BatchRejected(_, _ int) error { return nil }
+
+func (auctionMetricEmitterDelegate) CellStateCacheHits(_, _ int) error { return nil }
@@ -39,7 +39,7 @@ func (a *auctionRunnerDelegate) FetchCellReps(lager.Logger, string) (map[string]
 	return subset, nil
 }
 
-func (a *auctionRunnerDelegate) AuctionCompleted(logger lager.Logger, traceID string, work auctiontypes.AuctionResults) {
+func (a *auctionRunnerDelegate) AuctionCompleted(logger lager.Logger, traceIDs []string, work auctiontypes.AuctionResults) {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 	a.workResults.FailedLRPs = append(a.workResults.FailedLRPs, work.FailedLRPs...)
@@ -48,6 +48,8 @@ func (a *auctionRunnerDelegate) AuctionCompleted(logger lager.Logger, traceID st
 	a.workResults.SuccessfulTasks = append(a.workResults.SuccessfulTasks, work.SuccessfulTasks...)
 }
 
+func (a *auctionRunnerDelegate) HandBackAuctions(lager.Logger, auctiontypes.AuctionRequest) {}
+
 func (a *auctionRunnerDelegate) ResultSize() int {
 	a.lock.Lock()
 	defer a.lock.Unlock()
@@ -71,7 +71,8 @@ var _ = Describe("Auction", func() {
 
 	runStartAuction := func(lrpStartAuctions []auctioneer.LRPStartRequest, numCells int) {
 		runnerDelegate.SetCellLimit(numCells)
-		runner.ScheduleLRPsForAuctions(lrpStartAuctions, "some-trace-id")
+		err := runner.ScheduleLRPsForAuctions(lrpStartAuctions, "some-trace-id")
+		Expect(err).NotTo(HaveOccurred())
 
 		Eventually(runnerDelegate.ResultSize, 2*time.Minute, 100*time.Millisecond).Should(Equal(len(lrpStartAuctions)))
 	}